@@ -1,9 +1,17 @@
 package configs
 
-import "github.com/spf13/viper"
+import (
+	"strings"
 
+	"github.com/spf13/viper"
+)
+
+// Init reads configs/config.yaml. Environment variables override its keys
+// with dots replaced by underscores, e.g. ATTACHMENTS_S3_SECRETKEY.
 func Init() error {
 	viper.AddConfigPath("configs")
 	viper.SetConfigName("config")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
 	return viper.ReadInConfig()
 }
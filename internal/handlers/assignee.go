@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskAssignees godoc
+// @Summary Get the assignees of a task
+// @Description Retrieves the users assigned to a task, with who assigned them and when
+// @Tags assignees
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}{"assignees": []Assignee}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/assignees [get]
+func (h *Handler) TaskAssignees(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	assignees, err := h.services.IAssigneeService.Get(taskId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"assignees": assignees})
+}
+
+// PostAssignee godoc
+// @Summary Assign a user to a task
+// @Description Assigns a user who can see the task to it. Requires edit access; assigning a user twice changes nothing
+// @Tags assignees
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param userId path int true "User ID"
+// @Success 200 {string} string "User was assigned"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/assignees/{userId} [post]
+func (h *Handler) PostAssignee(c *gin.Context) {
+	userId, taskId, assigneeId, ok := h.assigneeParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.IAssigneeService.Assign(taskId, assigneeId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "User was assigned")
+}
+
+// DeleteAssignee godoc
+// @Summary Unassign a user from a task
+// @Description Removes a user from a task's assignees. Editors may unassign anyone, other users only themselves
+// @Tags assignees
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param userId path int true "User ID"
+// @Success 200 {string} string "User was unassigned"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/assignees/{userId} [delete]
+func (h *Handler) DeleteAssignee(c *gin.Context) {
+	userId, taskId, assigneeId, ok := h.assigneeParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.IAssigneeService.Unassign(taskId, assigneeId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "User was unassigned")
+}
+
+// AssignmentChanges godoc
+// @Summary Get the assignment history of a task
+// @Description Retrieves who was assigned to or unassigned from a task, by whom and when, oldest first
+// @Tags assignees
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}{"changes": []AssignmentChange}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/assignments [get]
+func (h *Handler) AssignmentChanges(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	changes, err := h.services.IAssigneeService.GetChanges(taskId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"changes": changes})
+}
+
+func (h *Handler) assigneeParams(c *gin.Context) (userId, taskId, assigneeId int, ok bool) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if taskId, err = strconv.Atoi(c.Param("id")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if assigneeId, err = strconv.Atoi(c.Param("userId")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	return userId, taskId, assigneeId, true
+}
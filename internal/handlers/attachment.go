@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskAttachments godoc
+// @Summary Get the attachments of a task
+// @Description Retrieves the metadata of the files attached to a task, oldest first
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}{"attachments": []Attachment}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/attachments [get]
+func (h *Handler) TaskAttachments(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	attachments, err := h.services.IAttachmentService.Get(taskId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"attachments": attachments})
+}
+
+// multipartOverhead is the room left in an upload for the multipart
+// boundaries and headers around the file.
+const multipartOverhead = 64 << 10
+
+// PostAttachment godoc
+// @Summary Attach a file to a task
+// @Description Uploads a file as the multipart field "file". The content type is detected from the file itself and must be one of the allowed types; files above the size limit are rejected
+// @Tags attachments
+// @Accept mpfd
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param file formData file true "File"
+// @Success 200 {object} gin.H{"id": int}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 413 {object} gin.H{"error": string}
+// @Router /tasks/{id}/attachments [post]
+func (h *Handler) PostAttachment(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	// the multipart form is parsed before the file size is known, so the
+	// body is cut off at the limit plus some room for the form itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.services.IAttachmentService.MaxSize()+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+			return
+		}
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	attachmentId, err := h.services.IAttachmentService.Create(taskId, userId, dtos.Upload{
+		FileName: header.Filename,
+		Size:     header.Size,
+		Content:  file,
+	})
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"id": attachmentId})
+}
+
+// DownloadAttachment godoc
+// @Summary Download an attachment
+// @Description Streams the content of an attached file under its original name
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "Task ID"
+// @Param attachmentId path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/attachments/{attachmentId} [get]
+func (h *Handler) DownloadAttachment(c *gin.Context) {
+	userId, taskId, attachmentId, ok := h.attachmentParams(c)
+	if !ok {
+		return
+	}
+	attachment, content, err := h.services.IAttachmentService.Open(taskId, attachmentId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	defer content.Close()
+	c.DataFromReader(200, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment godoc
+// @Summary Delete an attachment
+// @Description Removes a file from a task together with its stored content
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param attachmentId path int true "Attachment ID"
+// @Success 200 {string} string "Attachment deleted message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/attachments/{attachmentId} [delete]
+func (h *Handler) DeleteAttachment(c *gin.Context) {
+	userId, taskId, attachmentId, ok := h.attachmentParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.IAttachmentService.Delete(taskId, attachmentId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Attachment was deleted")
+}
+
+func (h *Handler) attachmentParams(c *gin.Context) (userId, taskId, attachmentId int, ok bool) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if taskId, err = strconv.Atoi(c.Param("id")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if attachmentId, err = strconv.Atoi(c.Param("attachmentId")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	return userId, taskId, attachmentId, true
+}
@@ -3,6 +3,10 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"slices"
+	"strings"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services"
 	"trackerApp/internal/services/dtos"
 
 	"github.com/gin-gonic/gin"
@@ -10,7 +14,7 @@ import (
 
 // AuthMiddleware godoc
 // @Summary Authorization middleware
-// @Description Middleware для проверки заголовка Authorization и валидации JWT токена.
+// @Description Middleware для проверки заголовка Authorization и валидации JWT токена. Принимает также API токены, начинающиеся с trk_.
 // @Tags middleware
 // @Accept json
 // @Produce json
@@ -26,19 +30,63 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := authHeader[len("Bearer "):]
+		tokenString, ok := strings.CutPrefix(authHeader, "Bearer ")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must hold a Bearer token"})
+			c.Abort()
+			return
+		}
 
-		userId, err := h.services.IAuthService.ParseJwt(tokenString)
+		var principal *models.Principal
+		var err error
+		if strings.HasPrefix(tokenString, services.ApiTokenPrefix) {
+			principal, err = h.services.IApiTokenService.ParseApiToken(tokenString)
+		} else {
+			principal, err = h.services.IAuthService.ParseJwt(tokenString)
+		}
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
-		c.Set("user_id", userId)
+		c.Set("user_id", principal.UserId)
+		c.Set("session_id", principal.SessionId)
+		c.Set("api_token_id", principal.ApiTokenId)
+		c.Set("scopes", principal.Scopes)
 		c.Next()
 	}
 }
 
+// RequireScope lets through requests whose token was granted scope, and
+// answers the others with 403 naming the missing scope.
+func (h *Handler) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requireScope(c, scope)
+	}
+}
+
+// RequireScopes is RequireScope with the read scope for GET requests and the
+// write scope for the rest.
+func (h *Handler) RequireScopes(read, write string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			requireScope(c, read)
+		} else {
+			requireScope(c, write)
+		}
+	}
+}
+
+func requireScope(c *gin.Context, scope string) {
+	scopes, _ := c.Get("scopes")
+	granted, _ := scopes.([]string)
+	if !slices.Contains(granted, scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token lacks the " + scope + " scope", "missing_scope": scope})
+		return
+	}
+	c.Next()
+}
+
 func (h *Handler) GetUserId(c *gin.Context) (int, error) {
 	id, ok := c.Get("user_id")
 	if !ok {
@@ -65,12 +113,37 @@ func (h *Handler) SignIn(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, "Invalid body request")
 		return
 	}
-	accessToken, err := h.services.IAuthService.GenerateJwt(request)
+	tokens, err := h.services.IAuthService.GenerateJwt(request)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"token": accessToken})
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Refresh godoc
+// @Summary Refresh the access token
+// @Description Trades a refresh token for a new access token and refresh token. Every refresh token is valid once; reusing one revokes the whole session
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dtos.RefreshForm true "Refresh token"
+// @Success 200 {object} models.Tokens
+// @Failure 400 {string} string "Invalid body request"
+// @Failure 401 {object} gin.H{"error": string}
+// @Router /refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	var request dtos.RefreshForm
+	if err := c.ShouldBindJSON(&request); err != nil || request.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, "Invalid body request")
+		return
+	}
+	tokens, err := h.services.IAuthService.Refresh(request.RefreshToken)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
 }
 
 // SignUp godoc
@@ -99,13 +172,40 @@ func (h *Handler) SignUp(c *gin.Context) {
 
 // Logout godoc
 // @Summary User logout
-// @Description Logs out the user by clearing the Authorization header.
+// @Description Logs out the user by revoking the session of the access token, together with its refresh tokens.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Success 200 {object} gin.H{"message": string} "Logout success message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
 // @Router /logout [post]
 func (h *Handler) Logout(c *gin.Context) {
-	c.Header("Authorization", "")
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sessionId := c.GetInt("session_id")
+	if sessionId == 0 {
+		c.JSON(400, gin.H{"error": "API tokens have no session to log out of, revoke them instead"})
+		return
+	}
+	if err := h.services.IAuthService.Logout(models.Principal{UserId: userId, SessionId: sessionId}); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
 	c.JSON(200, gin.H{"message": "Goodbye!"})
 }
+
+// JWKS godoc
+// @Summary Token verification keys
+// @Description Publishes the public keys access tokens are signed with as a JSON Web Key Set, so that other services can verify them. Tokens name their key in the kid header
+// @Tags auth
+// @Produce json
+// @Success 200 {object} signing.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.services.IAuthService.JWKS())
+}
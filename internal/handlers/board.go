@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBoard godoc
+// @Summary Get the board
+// @Description Retrieves the user's board: its columns in order, each with its tasks in manual (rank) order.
+// @Description A task is shown in the column it was moved to if that matches its completion state, otherwise in the first open or done column
+// @Tags board
+// @Accept json
+// @Produce json
+// @Param X-Workspace-Id header int false "Workspace ID; the user's personal tasks when omitted"
+// @Param project_id query int false "Only tasks of this project"
+// @Success 200 {object} map[string]interface{}{"board": Board}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /board [get]
+func (h *Handler) GetBoard(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	projectId, err := projectQuery(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	board, err := h.services.IBoardService.GetBoard(userId, workspaceId(c), projectId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"board": board})
+}
+
+// AllBoardColumns godoc
+// @Summary Get board columns
+// @Description Retrieves the columns of the user's board in order. New users start with an "Open" and a "Done" column
+// @Tags board
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}{"columns": []BoardColumn}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /board/columns [get]
+func (h *Handler) AllBoardColumns(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	columns, err := h.services.IBoardService.GetColumns(userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"columns": columns})
+}
+
+// PostBoardColumn godoc
+// @Summary Create a board column
+// @Description Adds a column to the user's board, at the end unless a position is given. Tasks moved into a done column are completed
+// @Tags board
+// @Accept json
+// @Produce json
+// @Param request body dtos.BoardColumnForm true "Column"
+// @Success 200 {object} gin.H{"id": int}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /board/columns [post]
+func (h *Handler) PostBoardColumn(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.BoardColumnForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	columnId, err := h.services.IBoardService.CreateColumn(userId, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"id": columnId})
+}
+
+// PutBoardColumn godoc
+// @Summary Update a board column
+// @Description Renames, reorders or changes the done flag of a column. The board always keeps at least one open and one done column
+// @Tags board
+// @Accept json
+// @Produce json
+// @Param id path int true "Column ID"
+// @Param request body dtos.BoardColumnForm true "Column"
+// @Success 200 {string} string "Column updated message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /board/columns/{id} [put]
+func (h *Handler) PutBoardColumn(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	columnId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.BoardColumnForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IBoardService.UpdateColumn(columnId, userId, request); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Column was updated")
+}
+
+// DeleteBoardColumn godoc
+// @Summary Delete a board column
+// @Description Deletes a column; its tasks move to the first column matching their completion state. The last open or done column cannot be deleted
+// @Tags board
+// @Accept json
+// @Produce json
+// @Param id path int true "Column ID"
+// @Success 200 {string} string "Column deleted message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /board/columns/{id} [delete]
+func (h *Handler) DeleteBoardColumn(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	columnId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IBoardService.DeleteColumn(columnId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Column was deleted")
+}
+
+// MoveCard godoc
+// @Summary Move a task on the board
+// @Description Moves a task into a column, right after the task after_id of that column or to its top when after_id is null.
+// @Description Moving between open and done columns completes or reopens the task, failing with 409 on open blockers or subtasks
+// @Tags board
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param request body dtos.MoveCard true "Target column and preceding task"
+// @Success 200 {string} string "Task moved message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /tasks/{id}/move [put]
+func (h *Handler) MoveCard(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.MoveCard
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IBoardService.MoveCard(taskId, userId, request); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Task was moved")
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskComments godoc
+// @Summary Get the comments of a task
+// @Description Retrieves the comments of a task, oldest first. Bodies are markdown and returned as written
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}{"comments": []Comment}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/comments [get]
+func (h *Handler) TaskComments(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	comments, err := h.services.ICommentService.Get(taskId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"comments": comments})
+}
+
+// PostComment godoc
+// @Summary Comment on a task
+// @Description Adds a markdown comment to a task
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param request body dtos.CommentForm true "Comment"
+// @Success 200 {object} gin.H{"id": int}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/comments [post]
+func (h *Handler) PostComment(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.CommentForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	commentId, err := h.services.ICommentService.Create(taskId, userId, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"id": commentId})
+}
+
+// PutComment godoc
+// @Summary Edit a comment
+// @Description Replaces the body of one of the user's own comments. The previous body is kept in the comment's versions
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param commentId path int true "Comment ID"
+// @Param request body dtos.CommentForm true "Comment"
+// @Success 200 {string} string "Comment updated message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/comments/{commentId} [put]
+func (h *Handler) PutComment(c *gin.Context) {
+	userId, taskId, commentId, ok := h.commentParams(c)
+	if !ok {
+		return
+	}
+	var request dtos.CommentForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.ICommentService.Update(taskId, commentId, userId, request); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Comment was updated")
+}
+
+// DeleteComment godoc
+// @Summary Delete a comment
+// @Description Deletes one of the user's own comments together with its versions
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param commentId path int true "Comment ID"
+// @Success 200 {string} string "Comment deleted message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/comments/{commentId} [delete]
+func (h *Handler) DeleteComment(c *gin.Context) {
+	userId, taskId, commentId, ok := h.commentParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.ICommentService.Delete(taskId, commentId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Comment was deleted")
+}
+
+// CommentVersions godoc
+// @Summary Get the edit history of a comment
+// @Description Retrieves the former bodies of a comment, oldest first, each with the time it was written
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param commentId path int true "Comment ID"
+// @Success 200 {object} map[string]interface{}{"versions": []CommentVersion}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/comments/{commentId}/versions [get]
+func (h *Handler) CommentVersions(c *gin.Context) {
+	userId, taskId, commentId, ok := h.commentParams(c)
+	if !ok {
+		return
+	}
+	versions, err := h.services.ICommentService.GetVersions(taskId, commentId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"versions": versions})
+}
+
+func (h *Handler) commentParams(c *gin.Context) (userId, taskId, commentId int, ok bool) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if taskId, err = strconv.Atoi(c.Param("id")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if commentId, err = strconv.Atoi(c.Param("commentId")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	return userId, taskId, commentId, true
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskBlockers godoc
+// @Summary Get the blockers of a task
+// @Description Retrieves the tasks that have to be done before the given task can be completed
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}{"tasks": []Task}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/blockers [get]
+func (h *Handler) TaskBlockers(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	tasks, err := h.services.IDependencyService.GetBlockers(taskId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"tasks": tasks})
+}
+
+// TaskDependents godoc
+// @Summary Get the dependents of a task
+// @Description Retrieves the tasks that are blocked by the given task
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}{"tasks": []Task}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/dependents [get]
+func (h *Handler) TaskDependents(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	tasks, err := h.services.IDependencyService.GetDependents(taskId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"tasks": tasks})
+}
+
+// PostBlocker godoc
+// @Summary Mark a task as blocked by another task
+// @Description Adds a "blocked by" relation. Relations that would create a cycle are rejected
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param blockerId path int true "Blocking task ID"
+// @Success 200 {string} string "Blocker added message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/blockers/{blockerId} [post]
+func (h *Handler) PostBlocker(c *gin.Context) {
+	userId, taskId, blockerId, ok := h.blockerParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.IDependencyService.AddBlocker(taskId, blockerId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Blocker was added")
+}
+
+// DeleteBlocker godoc
+// @Summary Remove a blocker from a task
+// @Description Removes a "blocked by" relation between two tasks
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param blockerId path int true "Blocking task ID"
+// @Success 200 {string} string "Blocker removed message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/blockers/{blockerId} [delete]
+func (h *Handler) DeleteBlocker(c *gin.Context) {
+	userId, taskId, blockerId, ok := h.blockerParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.IDependencyService.RemoveBlocker(taskId, blockerId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Blocker was removed")
+}
+
+// AvailableTasks godoc
+// @Summary Get the tasks that can be worked on now
+// @Description Retrieves open tasks without open blockers. Tasks that unblock the most other work come first
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Param X-Workspace-Id header int false "Workspace ID; the user's personal tasks when omitted"
+// @Success 200 {object} map[string]interface{}{"tasks": []Task}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /tasks/available [get]
+func (h *Handler) AvailableTasks(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tasks, err := h.services.IDependencyService.GetAvailable(userId, workspaceId(c))
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"tasks": tasks})
+}
+
+func (h *Handler) blockerParams(c *gin.Context) (userId, taskId, blockerId int, ok bool) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if taskId, err = strconv.Atoi(c.Param("id")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if blockerId, err = strconv.Atoi(c.Param("blockerId")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	return userId, taskId, blockerId, true
+}
@@ -1,11 +1,14 @@
 package handlers
 
 import (
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"errors"
+	"net/http"
+	"trackerApp/internal/models"
 	"trackerApp/internal/services"
 
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 type Handler struct {
@@ -20,24 +23,150 @@ func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/.well-known/jwks.json", h.JWKS)
 
 	api := router.Group("/api")
 	{
 		api.POST("/signIn", h.SignIn)
 		api.POST("/signUp", h.SignUp)
+		api.POST("/refresh", h.Refresh)
+		api.GET("/oidc/login", h.OIDCLogin)
+		api.GET("/oidc/callback", h.OIDCCallback)
 
-		protected := api.Group("/protected", h.AuthMiddleware())
+		// every route below declares the scopes a token needs for it, the read
+		// scope for GET and the write scope otherwise; logout needs none
+		protected := api.Group("/protected", h.AuthMiddleware(), h.WorkspaceMiddleware())
 		{
-			tasks := protected.Group("/tasks")
+			tasks := protected.Group("/tasks", h.RequireScopes(models.ScopeTasksRead, models.ScopeTasksWrite))
 			{
 				tasks.GET("/", h.AllTasks)
+				tasks.GET("/search", h.SearchTasks)
+				tasks.GET("/overdue", h.OverdueTasks)
+				tasks.GET("/due-today", h.TasksDueToday)
+				tasks.GET("/due", h.TasksDueWithin)
+				tasks.GET("/available", h.AvailableTasks)
+				tasks.GET("/shared", h.SharedTasks)
+				tasks.GET("/trash", h.TrashedTasks)
+				tasks.POST("/trash/:id/restore", h.RestoreTask)
+				tasks.DELETE("/trash/:id", h.PurgeTask)
 				tasks.GET("/:id", h.TaskById)
 				tasks.POST("/", h.PostTask)
 				tasks.PUT("/:id", h.PutTask)
 				tasks.DELETE("/:id", h.DeleteTask)
+				tasks.GET("/:id/subtree", h.TaskSubtree)
+				tasks.PUT("/:id/parent", h.PutTaskParent)
+				tasks.PUT("/:id/project", h.MoveTask)
+				tasks.GET("/:id/blockers", h.TaskBlockers)
+				tasks.POST("/:id/blockers/:blockerId", h.PostBlocker)
+				tasks.DELETE("/:id/blockers/:blockerId", h.DeleteBlocker)
+				tasks.GET("/:id/dependents", h.TaskDependents)
+				tasks.POST("/:id/labels/:labelId", h.AttachLabel)
+				tasks.DELETE("/:id/labels/:labelId", h.DetachLabel)
+				tasks.PUT("/:id/move", h.MoveCard)
+				tasks.GET("/:id/comments", h.TaskComments)
+				tasks.POST("/:id/comments", h.PostComment)
+				tasks.PUT("/:id/comments/:commentId", h.PutComment)
+				tasks.DELETE("/:id/comments/:commentId", h.DeleteComment)
+				tasks.GET("/:id/comments/:commentId/versions", h.CommentVersions)
+				tasks.GET("/:id/attachments", h.TaskAttachments)
+				tasks.POST("/:id/attachments", h.PostAttachment)
+				tasks.GET("/:id/attachments/:attachmentId", h.DownloadAttachment)
+				tasks.DELETE("/:id/attachments/:attachmentId", h.DeleteAttachment)
+				tasks.GET("/:id/shares", h.TaskShares)
+				tasks.POST("/:id/shares", h.PostTaskShare)
+				tasks.DELETE("/:id/shares/:userId", h.DeleteTaskShare)
+				tasks.GET("/:id/assignees", h.TaskAssignees)
+				tasks.POST("/:id/assignees/:userId", h.PostAssignee)
+				tasks.DELETE("/:id/assignees/:userId", h.DeleteAssignee)
+				tasks.GET("/:id/assignments", h.AssignmentChanges)
+				tasks.GET("/:id/history", h.TaskHistory)
+			}
+			protected.GET("/recurrence/preview", h.RequireScope(models.ScopeTasksRead), h.RecurrencePreview)
+			protected.GET("/workflow", h.RequireScope(models.ScopeBoardRead), h.GetWorkflow)
+			protected.PUT("/workflow", h.RequireScope(models.ScopeBoardWrite), h.PutWorkflow)
+			board := protected.Group("/board", h.RequireScopes(models.ScopeBoardRead, models.ScopeBoardWrite))
+			{
+				board.GET("/", h.GetBoard)
+				board.GET("/columns", h.AllBoardColumns)
+				board.POST("/columns", h.PostBoardColumn)
+				board.PUT("/columns/:id", h.PutBoardColumn)
+				board.DELETE("/columns/:id", h.DeleteBoardColumn)
 			}
-			protected.POST("/logout")
+			projects := protected.Group("/projects", h.RequireScopes(models.ScopeProjectsRead, models.ScopeProjectsWrite))
+			{
+				projects.GET("/", h.AllProjects)
+				projects.GET("/:id", h.ProjectById)
+				projects.POST("/", h.PostProject)
+				projects.PUT("/:id", h.PutProject)
+				projects.DELETE("/:id", h.DeleteProject)
+				projects.GET("/:id/tasks", h.RequireScope(models.ScopeTasksRead), h.ProjectTasks)
+				projects.POST("/:id/tasks", h.RequireScope(models.ScopeTasksWrite), h.PostProjectTask)
+				projects.GET("/:id/shares", h.ProjectShares)
+				projects.POST("/:id/shares", h.PostProjectShare)
+				projects.DELETE("/:id/shares/:userId", h.DeleteProjectShare)
+			}
+			workspaces := protected.Group("/workspaces", h.RequireScopes(models.ScopeWorkspacesRead, models.ScopeWorkspacesWrite))
+			{
+				workspaces.GET("/", h.AllWorkspaces)
+				workspaces.POST("/", h.PostWorkspace)
+				workspaces.PUT("/:id", h.PutWorkspace)
+				workspaces.DELETE("/:id", h.DeleteWorkspace)
+				workspaces.GET("/:id/members", h.WorkspaceMembers)
+				workspaces.PUT("/:id/members/:userId", h.PutMemberRole)
+				workspaces.DELETE("/:id/members/:userId", h.DeleteMember)
+				workspaces.POST("/:id/invitations", h.PostInvitation)
+			}
+			invitations := protected.Group("/invitations", h.RequireScopes(models.ScopeWorkspacesRead, models.ScopeWorkspacesWrite))
+			{
+				invitations.GET("/", h.AllInvitations)
+				invitations.POST("/:id/accept", h.AcceptInvitation)
+				invitations.POST("/:id/decline", h.DeclineInvitation)
+			}
+			views := protected.Group("/views", h.RequireScopes(models.ScopeViewsRead, models.ScopeViewsWrite))
+			{
+				views.GET("/", h.AllViews)
+				views.GET("/:id", h.ViewById)
+				views.POST("/", h.PostView)
+				views.PUT("/:id", h.PutView)
+				views.DELETE("/:id", h.DeleteView)
+				views.GET("/:id/tasks", h.RequireScope(models.ScopeTasksRead), h.ViewTasks)
+			}
+			labels := protected.Group("/labels", h.RequireScopes(models.ScopeLabelsRead, models.ScopeLabelsWrite))
+			{
+				labels.GET("/", h.AllLabels)
+				labels.POST("/", h.PostLabel)
+				labels.PUT("/:id", h.PutLabel)
+				labels.DELETE("/:id", h.DeleteLabel)
+			}
+			tokens := protected.Group("/tokens", h.RequireScopes(models.ScopeTokensRead, models.ScopeTokensWrite))
+			{
+				tokens.GET("/", h.AllApiTokens)
+				tokens.POST("/", h.PostApiToken)
+				tokens.DELETE("/:id", h.DeleteApiToken)
+			}
+			protected.GET("/activity", h.RequireScope(models.ScopeTasksRead), h.MyActivity)
+			// linking an identity is session-only, like managing tokens
+			protected.POST("/oidc/link", h.RequireScope(models.ScopeTokensWrite), h.OIDCLink)
+			protected.POST("/oidc/link/callback", h.RequireScope(models.ScopeTokensWrite), h.OIDCLinkCallback)
+			protected.POST("/logout", h.Logout)
 		}
 	}
 	return router
 }
+
+func newErrorResponse(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidInput):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrUnauthorized):
+		status = http.StatusUnauthorized
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+}
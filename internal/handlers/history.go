@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskHistory godoc
+// @Summary Get the change history of a task
+// @Description Retrieves who created, changed, deleted or restored a task and when, oldest first. Updates carry the before and after values of the changed fields
+// @Tags history
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}{"changes": []TaskChange}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/history [get]
+func (h *Handler) TaskHistory(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	changes, err := h.services.IHistoryService.GetTaskHistory(taskId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"changes": changes})
+}
+
+// MyActivity godoc
+// @Summary Get the user's recent activity
+// @Description Retrieves a page of the task changes the user made, most recent first
+// @Tags history
+// @Accept json
+// @Produce json
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} models.ActivityPage
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /activity [get]
+func (h *Handler) MyActivity(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var page dtos.ActivityPage
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	activity, err := h.services.IHistoryService.GetActivity(userId, page)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, activity)
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AllLabels godoc
+// @Summary Get all labels of a user
+// @Description Retrieves the labels owned by the user ID obtained from the context, ordered by name
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}{"labels": []Label}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /labels [get]
+func (h *Handler) AllLabels(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	labels, err := h.services.ILabelService.Get(userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"labels": labels})
+}
+
+// PostLabel godoc
+// @Summary Create a label
+// @Description Creates a new label owned by the user ID obtained from the context. Names are unique per user
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param request body dtos.LabelForm true "Label"
+// @Success 200 {object} gin.H{"id": int}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /labels [post]
+func (h *Handler) PostLabel(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.LabelForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	labelId, err := h.services.ILabelService.Create(userId, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"id": labelId})
+}
+
+// PutLabel godoc
+// @Summary Rename or recolor a label
+// @Description Updates a label owned by the user ID obtained from the context
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path int true "Label ID"
+// @Param request body dtos.LabelForm true "Label"
+// @Success 200 {string} string "Label updated message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /labels/{id} [put]
+func (h *Handler) PutLabel(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	labelId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.LabelForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.ILabelService.Update(labelId, userId, request); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Label was updated")
+}
+
+// DeleteLabel godoc
+// @Summary Delete a label
+// @Description Deletes a label and detaches it from all tasks
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path int true "Label ID"
+// @Success 200 {string} string "Label deleted message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /labels/{id} [delete]
+func (h *Handler) DeleteLabel(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	labelId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.ILabelService.Delete(labelId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Label was deleted")
+}
+
+// AttachLabel godoc
+// @Summary Attach a label to a task
+// @Description Attaches one of the user's labels to one of the user's tasks. Attaching twice is a no-op
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param labelId path int true "Label ID"
+// @Success 200 {string} string "Label attached message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/labels/{labelId} [post]
+func (h *Handler) AttachLabel(c *gin.Context) {
+	userId, taskId, labelId, ok := h.taskLabelParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.ILabelService.Attach(taskId, labelId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Label was attached")
+}
+
+// DetachLabel godoc
+// @Summary Detach a label from a task
+// @Description Removes a label from a task without deleting the label itself
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param labelId path int true "Label ID"
+// @Success 200 {string} string "Label detached message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/labels/{labelId} [delete]
+func (h *Handler) DetachLabel(c *gin.Context) {
+	userId, taskId, labelId, ok := h.taskLabelParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.ILabelService.Detach(taskId, labelId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Label was detached")
+}
+
+func (h *Handler) taskLabelParams(c *gin.Context) (userId, taskId, labelId int, ok bool) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if taskId, err = strconv.Atoi(c.Param("id")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if labelId, err = strconv.Atoi(c.Param("labelId")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	return userId, taskId, labelId, true
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie binds a sign-in to the browser that started it. The
+// callback is refused unless the browser brings the same state back.
+const oidcStateCookie = "oidc_state"
+
+func setOIDCState(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, "/api", "", true, true)
+}
+
+// oidcCallbackParams reads what the identity provider sent the user back
+// with and the state bound to the browser, which is used up either way.
+func oidcCallbackParams(c *gin.Context) (state, boundState, code string, ok bool) {
+	boundState, _ = c.Cookie(oidcStateCookie)
+	setOIDCState(c, "", -1)
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": providerErr + ": " + c.Query("error_description")})
+		return "", "", "", false
+	}
+	code, state = c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(400, gin.H{"error": "code and state are required"})
+		return "", "", "", false
+	}
+	return state, boundState, code, true
+}
+
+// OIDCLogin godoc
+// @Summary Sign in with single sign-on
+// @Description Redirects to the identity provider to sign in with the authorization code flow and PKCE, and binds the sign-in to the browser with an HttpOnly cookie. The provider sends the user back to /oidc/callback
+// @Tags auth
+// @Success 302 {string} string "Redirect to the identity provider"
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /oidc/login [get]
+func (h *Handler) OIDCLogin(c *gin.Context) {
+	url, state, err := h.services.IOIDCService.StartLogin()
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	setOIDCState(c, state, 0)
+	c.Redirect(http.StatusFound, url)
+}
+
+// OIDCCallback godoc
+// @Summary Complete single sign-on
+// @Description Checks the sign-in the identity provider sent the user back from and starts a session. The browser has to bring back the cookie set when the sign-in started. An identity seen for the first time creates a user named after its verified email when auto-provisioning is on. If an account of that name exists, the sign-in fails with 409 until its user links the identity at /oidc/link
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State of the sign-in"
+// @Success 200 {object} models.Tokens
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 401 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /oidc/callback [get]
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	state, boundState, code, ok := oidcCallbackParams(c)
+	if !ok {
+		return
+	}
+	tokens, err := h.services.IOIDCService.FinishLogin(state, boundState, code)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// OIDCLink godoc
+// @Summary Link a single sign-on identity
+// @Description Starts a sign-in with the identity provider that links the identity to the signed-in user, so that the user can sign in with it from then on, and binds it to the browser with an HttpOnly cookie. Send the user to the returned URL; once the provider sends them back, pass the code and state on to /oidc/link/callback as the same user
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{}{"url": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /oidc/link [post]
+func (h *Handler) OIDCLink(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	url, state, err := h.services.IOIDCService.StartLink(userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	setOIDCState(c, state, 0)
+	c.JSON(http.StatusOK, gin.H{"url": url})
+}
+
+// OIDCLinkCallback godoc
+// @Summary Complete linking a single sign-on identity
+// @Description Checks the sign-in the identity provider sent the user back from and links the identity to the signed-in user, who has to be the one that started the link in the same browser
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State of the sign-in"
+// @Success 200 {string} string "Identity linked message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 401 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /oidc/link/callback [post]
+func (h *Handler) OIDCLinkCallback(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	state, boundState, code, ok := oidcCallbackParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.IOIDCService.FinishLink(userId, state, boundState, code); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, "Identity was linked")
+}
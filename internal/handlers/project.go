@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AllProjects godoc
+// @Summary Get projects of a user
+// @Description Retrieves the projects owned by the user ID obtained from the context. Archived projects are hidden unless requested
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param archived query bool false "Include archived projects"
+// @Success 200 {object} map[string]interface{}{"projects": []Project}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /projects [get]
+func (h *Handler) AllProjects(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	includeArchived, _ := strconv.ParseBool(c.Query("archived"))
+	projects, err := h.services.IProjectService.Get(userId, includeArchived)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"projects": projects})
+}
+
+// ProjectById godoc
+// @Summary Get project by ID
+// @Description Retrieves a project owned by the user ID obtained from the context
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} map[string]interface{}{"project": Project}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /projects/{id} [get]
+func (h *Handler) ProjectById(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	projectId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	project, err := h.services.IProjectService.GetById(projectId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"project": project})
+}
+
+// PostProject godoc
+// @Summary Create a project
+// @Description Creates a new project owned by the user ID obtained from the context
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param request body dtos.CreateProject true "Create project request"
+// @Success 200 {object} gin.H{"id": int}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /projects [post]
+func (h *Handler) PostProject(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.CreateProject
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	projectId, err := h.services.IProjectService.Create(userId, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"id": projectId})
+}
+
+// PutProject godoc
+// @Summary Update a project
+// @Description Updates name, description, color and the archived flag of a project
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param request body dtos.UpdateProject true "Update project request"
+// @Success 200 {string} string "Project updated message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /projects/{id} [put]
+func (h *Handler) PutProject(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	projectId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.UpdateProject
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IProjectService.Update(projectId, userId, request); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Project was updated")
+}
+
+// DeleteProject godoc
+// @Summary Delete a project
+// @Description Deletes a project. Its tasks are either moved to the inbox (default) or to the trash
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param tasks query string false "What to do with the tasks: inbox or cascade"
+// @Success 200 {string} string "Project deleted message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /projects/{id} [delete]
+func (h *Handler) DeleteProject(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	projectId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IProjectService.Delete(projectId, userId, c.Query("tasks")); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Project was deleted")
+}
+
+// ProjectTasks godoc
+// @Summary Get a page of tasks in a project
+// @Description Same as GET /tasks, restricted to the given project
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.TaskPage
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /projects/{id}/tasks [get]
+func (h *Handler) ProjectTasks(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	projectId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var filter dtos.TaskFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := h.services.IProjectService.GetById(projectId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	filter.ProjectId = &projectId
+	page, err := h.services.ITaskService.Get(userId, filter)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, page)
+}
+
+// PostProjectTask godoc
+// @Summary Create a task in a project
+// @Description Creates a new task inside the given project
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param request body dtos.CreateTask true "Create task request"
+// @Success 200 {string} string "Task created message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /projects/{id}/tasks [post]
+func (h *Handler) PostProjectTask(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	projectId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.CreateTask
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	request.ProjectId = &projectId
+	taskId, err := h.services.ITaskService.Create(userId, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, fmt.Sprintf("Task %d was created", taskId))
+}
+
+// MoveTask godoc
+// @Summary Move a task to another project
+// @Description Moves a task into the given project, or to the inbox when project_id is null
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param request body dtos.MoveTask true "Target project"
+// @Success 200 {string} string "Task moved message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/project [put]
+func (h *Handler) MoveTask(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.MoveTask
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IProjectService.MoveTask(taskId, userId, request.ProjectId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Task was moved")
+}
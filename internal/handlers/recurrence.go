@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecurrencePreview godoc
+// @Summary Preview the occurrences of a recurrence rule
+// @Description Lists the next occurrences of an RFC 5545 RRULE (FREQ, INTERVAL, BYDAY, UNTIL, COUNT), starting with the start time itself
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param rule query string true "Recurrence rule, e.g. FREQ=WEEKLY;BYDAY=MO,WE"
+// @Param tz query string false "IANA time zone the rule is evaluated in (UTC by default)"
+// @Param start query string false "First occurrence (RFC 3339), now by default"
+// @Param count query int false "Number of occurrences (default 5, max 100)"
+// @Success 200 {object} map[string]interface{}{"occurrences": []string}
+// @Failure 400 {object} gin.H{"error": string}
+// @Router /recurrence/preview [get]
+func (h *Handler) RecurrencePreview(c *gin.Context) {
+	start := time.Now()
+	if value := c.Query("start"); value != "" {
+		var err error
+		if start, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", "5"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	recurrence := dtos.Recurrence{Rule: c.Query("rule"), TimeZone: c.Query("tz")}
+	occurrences, err := h.services.ITaskService.PreviewRecurrence(recurrence, start, count)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"occurrences": occurrences})
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SharedTasks godoc
+// @Summary Get the tasks shared with the user
+// @Description Retrieves the tasks other users shared with the user, directly or through a project, with the owner and the strongest role granted
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}{"tasks": []SharedTask}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /tasks/shared [get]
+func (h *Handler) SharedTasks(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tasks, err := h.services.IShareService.GetSharedWithMe(userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"tasks": tasks})
+}
+
+// TaskShares godoc
+// @Summary Get the shares of a task
+// @Description Retrieves the users a task is shared with. Only the owner may list them
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}{"shares": []Share}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/shares [get]
+func (h *Handler) TaskShares(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	shares, err := h.services.IShareService.GetTaskShares(taskId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"shares": shares})
+}
+
+// PostTaskShare godoc
+// @Summary Share a task
+// @Description Shares a task with a user as "viewer" or "editor", or changes the role of an existing share. Only the owner may share
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param request body dtos.ShareForm true "Share"
+// @Success 200 {string} string "Task was shared"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/shares [post]
+func (h *Handler) PostTaskShare(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.ShareForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IShareService.ShareTask(taskId, userId, request); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Task was shared")
+}
+
+// DeleteTaskShare godoc
+// @Summary Revoke a task share
+// @Description Revokes a user's access to a task with immediate effect. The owner may revoke any share, other users only their own
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param userId path int true "User ID"
+// @Success 200 {string} string "Share was revoked"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/shares/{userId} [delete]
+func (h *Handler) DeleteTaskShare(c *gin.Context) {
+	userId, taskId, shareUserId, ok := h.shareParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.IShareService.RevokeTask(taskId, shareUserId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Share was revoked")
+}
+
+// ProjectShares godoc
+// @Summary Get the shares of a project
+// @Description Retrieves the users a project is shared with. Only the owner may list them
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} map[string]interface{}{"shares": []Share}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /projects/{id}/shares [get]
+func (h *Handler) ProjectShares(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	projectId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	shares, err := h.services.IShareService.GetProjectShares(projectId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"shares": shares})
+}
+
+// PostProjectShare godoc
+// @Summary Share a project
+// @Description Shares every task of a project with a user as "viewer" or "editor", or changes the role of an existing share. Only the owner may share
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param request body dtos.ShareForm true "Share"
+// @Success 200 {string} string "Project was shared"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /projects/{id}/shares [post]
+func (h *Handler) PostProjectShare(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	projectId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.ShareForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IShareService.ShareProject(projectId, userId, request); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Project was shared")
+}
+
+// DeleteProjectShare godoc
+// @Summary Revoke a project share
+// @Description Revokes a user's access to a project's tasks with immediate effect. The owner may revoke any share, other users only their own
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param userId path int true "User ID"
+// @Success 200 {string} string "Share was revoked"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /projects/{id}/shares/{userId} [delete]
+func (h *Handler) DeleteProjectShare(c *gin.Context) {
+	userId, projectId, shareUserId, ok := h.shareParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.IShareService.RevokeProject(projectId, shareUserId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Share was revoked")
+}
+
+func (h *Handler) shareParams(c *gin.Context) (userId, id, shareUserId int, ok bool) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if id, err = strconv.Atoi(c.Param("id")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if shareUserId, err = strconv.Atoi(c.Param("userId")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	return userId, id, shareUserId, true
+}
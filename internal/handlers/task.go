@@ -4,18 +4,40 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 	"trackerApp/internal/services/dtos"
 
 	"github.com/gin-gonic/gin"
 )
 
 // AllTasks godoc
-// @Summary Get all tasks for a user
-// @Description Retrieves all tasks associated with the user ID obtained from the context
+// @Summary Get a page of tasks for a user
+// @Description Retrieves tasks associated with the user ID obtained from the context, filtered, sorted and paginated by cursor. Without any criteria (only limit and cursor) the user's default view applies and its id is returned as view_id. The q filter takes key:value terms (is, has, title, desc, created, updated, completed, start, due, priority, status, label, project, assignee, creator), bare words, a leading minus to negate, OR and parentheses
 // @Tags tasks
 // @Accept json
 // @Produce json
-// @Success 200 {object} map[string]interface{}{"tasks": []Task}
+// @Param X-Workspace-Id header int false "Workspace ID; the user's personal tasks when omitted"
+// @Param completed query bool false "Only completed (true) or open (false) tasks"
+// @Param title query string false "Title substring"
+// @Param desc query string false "Description substring"
+// @Param created_from query string false "Created at or after (RFC 3339)"
+// @Param created_to query string false "Created before (RFC 3339)"
+// @Param label query []string false "Label name; repeat to require several labels"
+// @Param project_id query int false "Only tasks of this project"
+// @Param inbox query bool false "Only tasks without a project"
+// @Param parent_id query int false "Only direct subtasks of this task"
+// @Param status query string false "Workflow status name"
+// @Param priority query string false "Priority: low, medium, high, urgent"
+// @Param assigned_to_me query bool false "Only tasks the user is assigned to; without a workspace, from every task the user can view"
+// @Param created_by_me query bool false "Only tasks the user created"
+// @Param unassigned query bool false "Only tasks without assignees"
+// @Param q query string false "Filter query, e.g. is:open created:>2024-10-01 title:\"release\" -desc:wip"
+// @Param sort query string false "Sort key: created, updated, title, completion, priority"
+// @Param order query string false "Sort order: asc or desc"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} models.TaskPage
+// @Failure 400 {object} gin.H{"error": string}
 // @Failure 500 {object} gin.H{"error": string}
 // @Router /tasks [get]
 func (h *Handler) AllTasks(c *gin.Context) {
@@ -24,9 +46,139 @@ func (h *Handler) AllTasks(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	tasks, err := h.services.ITaskService.Get(userId)
+	var filter dtos.TaskFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	filter.WorkspaceId = workspaceId(c)
+	page, err := h.services.ITaskService.Get(userId, filter)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, page)
+}
+
+// SearchTasks godoc
+// @Summary Search tasks
+// @Description Full-text search over the titles and descriptions of the user's tasks, best matches first. Words are matched by their stem, "quoted phrases" as a whole, word* by prefix, and a leading minus excludes a term. Snippets are HTML with the matches wrapped in <mark> tags
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param X-Workspace-Id header int false "Workspace ID; the user's personal tasks when omitted"
+// @Param q query string true "Search query"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param offset query int false "Number of results to skip"
+// @Success 200 {object} models.SearchPage
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /tasks/search [get]
+func (h *Handler) SearchTasks(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var search dtos.TaskSearch
+	if err := c.ShouldBindQuery(&search); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	search.WorkspaceId = workspaceId(c)
+	page, err := h.services.ITaskService.Search(userId, search)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, page)
+}
+
+// OverdueTasks godoc
+// @Summary Get overdue tasks for a user
+// @Description Retrieves open tasks whose due date has already passed, earliest first
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param X-Workspace-Id header int false "Workspace ID; the user's personal tasks when omitted"
+// @Success 200 {object} map[string]interface{}{"tasks": []Task}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /tasks/overdue [get]
+func (h *Handler) OverdueTasks(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tasks, err := h.services.ITaskService.GetOverdue(userId, workspaceId(c), time.Now())
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"tasks": tasks})
+}
+
+// TasksDueToday godoc
+// @Summary Get tasks due today for a user
+// @Description Retrieves tasks due between the start and the end of the current day in the given time zone (UTC by default)
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param X-Workspace-Id header int false "Workspace ID; the user's personal tasks when omitted"
+// @Param tz query string false "IANA time zone, e.g. Europe/Moscow"
+// @Success 200 {object} map[string]interface{}{"tasks": []Task}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /tasks/due-today [get]
+func (h *Handler) TasksDueToday(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	tasks, err := h.services.ITaskService.GetDueBetween(userId, workspaceId(c), from, from.AddDate(0, 0, 1))
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"tasks": tasks})
+}
+
+// TasksDueWithin godoc
+// @Summary Get tasks due within N days for a user
+// @Description Retrieves tasks due between now and now plus the given number of days
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param X-Workspace-Id header int false "Workspace ID; the user's personal tasks when omitted"
+// @Param days query int true "Number of days ahead"
+// @Success 200 {object} map[string]interface{}{"tasks": []Task}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /tasks/due [get]
+func (h *Handler) TasksDueWithin(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	days, err := strconv.Atoi(c.Query("days"))
+	if err != nil || days <= 0 {
+		c.JSON(400, gin.H{"error": "days must be a positive integer"})
+		return
+	}
+	now := time.Now()
+	tasks, err := h.services.ITaskService.GetDueBetween(userId, workspaceId(c), now, now.AddDate(0, 0, days))
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		newErrorResponse(c, err)
+		return
 	}
 	c.JSON(200, gin.H{"tasks": tasks})
 }
@@ -51,10 +203,12 @@ func (h *Handler) TaskById(c *gin.Context) {
 	taskId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
 	task, err := h.services.ITaskService.GetById(taskId, userId)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		newErrorResponse(c, err)
+		return
 	}
 	c.JSON(200, gin.H{"task": task})
 }
@@ -65,6 +219,7 @@ func (h *Handler) TaskById(c *gin.Context) {
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Param X-Workspace-Id header int false "Workspace ID; the user's personal tasks when omitted"
 // @Param request body dtos.CreateTask true "Create task request"
 // @Success 200 {string} string "Task created message"
 // @Failure 400 {object} gin.H{"error": string}
@@ -79,17 +234,25 @@ func (h *Handler) PostTask(c *gin.Context) {
 	var request dtos.CreateTask
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
+	request.WorkspaceId = workspaceId(c)
 	taskId, err := h.services.ITaskService.Create(userId, request)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		newErrorResponse(c, err)
+		return
 	}
 	c.JSON(200, fmt.Sprintf("Task %d was created", taskId))
 }
 
 // PutTask godoc
 // @Summary Update an existing task for a user
-// @Description Updates a task associated with the given task ID and user ID obtained from the context
+// @Description Updates a task associated with the given task ID and user ID obtained from the context.
+// @Description Completing a task with open subtasks fails with 409 unless on_open_subtasks is "complete".
+// @Description Completing a task with open blockers fails with 409 unless force is true.
+// @Description Completing a recurring task creates its next occurrence.
+// @Description status_id must be reachable from the current status in the task's workflow; without it, is_complete moves the task to the first allowed terminal or non-terminal status.
+// @Description Editors of a shared task may update it; viewers get 403
 // @Tags tasks
 // @Accept json
 // @Produce json
@@ -97,6 +260,9 @@ func (h *Handler) PostTask(c *gin.Context) {
 // @Param request body dtos.UpdateTask true "Update task request"
 // @Success 200 {string} string "Task updated message"
 // @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
 // @Failure 500 {object} gin.H{"error": string}
 // @Router /tasks/{id} [put]
 func (h *Handler) PutTask(c *gin.Context) {
@@ -109,26 +275,30 @@ func (h *Handler) PutTask(c *gin.Context) {
 	taskId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
 	if err := h.services.ITaskService.Update(taskId, userId, request); err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		newErrorResponse(c, err)
+		return
 	}
 	c.JSON(200, "Task was updated")
 }
 
 // DeleteTask godoc
 // @Summary Delete a task for a user
-// @Description Deletes a specific task associated with the given task ID and user ID obtained from the context
+// @Description Moves a task with its subtasks to the trash, from which it can be restored until it is purged
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param id path int true "Task ID"
 // @Success 200 {string} string "Task deleted message"
 // @Failure 400 {object} gin.H{"error": string}
-// @Failure 500 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
 // @Router /tasks/{id} [delete]
 func (h *Handler) DeleteTask(c *gin.Context) {
 	userId, err := h.GetUserId(c)
@@ -139,9 +309,76 @@ func (h *Handler) DeleteTask(c *gin.Context) {
 	taskId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
 	if err := h.services.ITaskService.Delete(taskId, userId); err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		newErrorResponse(c, err)
+		return
 	}
 	c.JSON(200, "Task was deleted")
 }
+
+// TaskSubtree godoc
+// @Summary Get a task with all its subtasks
+// @Description Retrieves a task and its whole subtree. Every node carries the percentage of its completed descendants
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} map[string]interface{}{"task": TaskNode}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/subtree [get]
+func (h *Handler) TaskSubtree(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	node, err := h.services.ITaskService.GetSubtree(taskId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"task": node})
+}
+
+// PutTaskParent godoc
+// @Summary Make a task a subtask of another task
+// @Description Sets the parent of a task, or turns it into a top-level task when parent_task_id is null. Cycles are rejected
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param request body dtos.SetParent true "New parent"
+// @Success 200 {string} string "Task parent updated message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/{id}/parent [put]
+func (h *Handler) PutTaskParent(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.SetParent
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.ITaskService.SetParent(taskId, userId, request.ParentTaskId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Task parent was updated")
+}
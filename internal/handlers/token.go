@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AllApiTokens godoc
+// @Summary Get the API tokens of a user
+// @Description Retrieves the API tokens of the user ID obtained from the context that were not revoked, with their scopes, expiry and when they were last used. Secrets are never shown again
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}{"tokens": []ApiToken}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /tokens [get]
+func (h *Handler) AllApiTokens(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tokens, err := h.services.IApiTokenService.Get(userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"tokens": tokens})
+}
+
+// PostApiToken godoc
+// @Summary Create an API token
+// @Description Creates a token for scripts and other services, sent as "Authorization: Bearer trk_…". It is limited to its scopes (tasks:read, tasks:write, projects:read, …) and never expires unless expires_at is set. The secret is in the response only; store it, it cannot be shown again
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Param request body dtos.ApiTokenForm true "Token"
+// @Success 200 {object} models.NewApiToken
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /tokens [post]
+func (h *Handler) PostApiToken(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.ApiTokenForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	token, err := h.services.IApiTokenService.Create(userId, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, token)
+}
+
+// DeleteApiToken godoc
+// @Summary Revoke an API token
+// @Description Revokes an API token of the user; requests made with it fail from then on
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Param id path int true "Token ID"
+// @Success 200 {string} string "Token was revoked"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tokens/{id} [delete]
+func (h *Handler) DeleteApiToken(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tokenId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IApiTokenService.Revoke(tokenId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Token was revoked")
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashedTasks godoc
+// @Summary Get the tasks in the trash
+// @Description Retrieves the deleted tasks of a workspace or the user's personal ones, most recently deleted first. Subtasks deleted with their parent are not listed separately
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param X-Workspace-Id header int false "Workspace ID; the user's personal tasks when omitted"
+// @Success 200 {object} map[string]interface{}{"tasks": []TrashedTask}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /tasks/trash [get]
+func (h *Handler) TrashedTasks(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tasks, err := h.services.ITaskService.GetTrash(userId, workspaceId(c))
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"tasks": tasks})
+}
+
+// RestoreTask godoc
+// @Summary Restore a task from the trash
+// @Description Restores a deleted task together with the subtasks deleted with it. A subtask cannot be restored while its parent is in the trash
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {string} string "Task restored message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /tasks/trash/{id}/restore [post]
+func (h *Handler) RestoreTask(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.ITaskService.Restore(taskId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Task was restored")
+}
+
+// PurgeTask godoc
+// @Summary Delete a task in the trash for good
+// @Description Permanently deletes a task in the trash with its subtasks, comments and attachments
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {string} string "Task purged message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /tasks/trash/{id} [delete]
+func (h *Handler) PurgeTask(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taskId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.ITaskService.Purge(taskId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Task was purged")
+}
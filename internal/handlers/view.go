@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AllViews godoc
+// @Summary Get all saved views of a user
+// @Description Retrieves the saved task list views of the user ID obtained from the context, ordered by name
+// @Tags views
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}{"views": []SavedView}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /views [get]
+func (h *Handler) AllViews(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	views, err := h.services.IViewService.Get(userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"views": views})
+}
+
+// ViewById godoc
+// @Summary Get saved view by ID
+// @Description Retrieves a saved view of the user ID obtained from the context
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param id path int true "View ID"
+// @Success 200 {object} map[string]interface{}{"view": SavedView}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /views/{id} [get]
+func (h *Handler) ViewById(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	viewId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	view, err := h.services.IViewService.GetById(viewId, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"view": view})
+}
+
+// PostView godoc
+// @Summary Save a view
+// @Description Saves task list criteria and sort order under a name. The criteria are checked like the query parameters of the task list. A new default view replaces the previous one
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param request body dtos.ViewForm true "View"
+// @Success 200 {object} gin.H{"id": int}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /views [post]
+func (h *Handler) PostView(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.ViewForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	viewId, err := h.services.IViewService.Create(userId, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"id": viewId})
+}
+
+// PutView godoc
+// @Summary Update a saved view
+// @Description Replaces the name, criteria, sort order and default flag of a saved view
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param id path int true "View ID"
+// @Param request body dtos.ViewForm true "View"
+// @Success 200 {string} string "View was updated"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /views/{id} [put]
+func (h *Handler) PutView(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	viewId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.ViewForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IViewService.Update(viewId, userId, request); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "View was updated")
+}
+
+// DeleteView godoc
+// @Summary Delete a saved view
+// @Description Deletes a saved view. Deleting the default view leaves the task list unfiltered
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param id path int true "View ID"
+// @Success 200 {string} string "View was deleted"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /views/{id} [delete]
+func (h *Handler) DeleteView(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	viewId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IViewService.Delete(viewId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "View was deleted")
+}
+
+// ViewTasks godoc
+// @Summary Get the tasks of a saved view
+// @Description Retrieves a page of the tasks matching a saved view, in its sort order
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param X-Workspace-Id header int false "Workspace ID; the user's personal tasks when omitted"
+// @Param id path int true "View ID"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} models.TaskPage
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /views/{id}/tasks [get]
+func (h *Handler) ViewTasks(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	viewId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var page dtos.ViewPage
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	tasks, err := h.services.IViewService.GetTasks(viewId, userId, workspaceId(c), page)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, tasks)
+}
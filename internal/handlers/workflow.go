@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// projectQuery reads the optional project_id query parameter; nil means no
+// project was given.
+func projectQuery(c *gin.Context) (*int, error) {
+	value := c.Query("project_id")
+	if value == "" {
+		return nil, nil
+	}
+	projectId, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &projectId, nil
+}
+
+// GetWorkflow godoc
+// @Summary Get a workflow
+// @Description Retrieves the statuses and allowed transitions governing the tasks of a project, or the user's default workflow when no project is given. Projects without their own workflow use the default one
+// @Tags workflow
+// @Accept json
+// @Produce json
+// @Param project_id query int false "Project ID"
+// @Success 200 {object} map[string]interface{}{"workflow": Workflow}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /workflow [get]
+func (h *Handler) GetWorkflow(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	projectId, err := projectQuery(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	workflow, err := h.services.IWorkflowService.Get(userId, projectId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"workflow": workflow})
+}
+
+// PutWorkflow godoc
+// @Summary Replace a workflow
+// @Description Sets the ordered statuses and allowed transitions of the user's default workflow or of a project's own workflow.
+// @Description Statuses are matched by name; tasks in removed statuses move to a status with the same completion state. Without transitions every move is allowed
+// @Tags workflow
+// @Accept json
+// @Produce json
+// @Param project_id query int false "Project ID"
+// @Param request body dtos.Workflow true "Workflow"
+// @Success 200 {object} string "Workflow was updated"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /workflow [put]
+func (h *Handler) PutWorkflow(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	projectId, err := projectQuery(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.Workflow
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IWorkflowService.Replace(userId, projectId, request); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Workflow was updated")
+}
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"trackerApp/internal/services/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// workspaceHeader selects the workspace a request works on. Without it task
+// endpoints work on the user's personal tasks.
+const workspaceHeader = "X-Workspace-Id"
+
+// WorkspaceMiddleware reads the workspace header and checks that the user
+// belongs to the workspace.
+func (h *Handler) WorkspaceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.GetHeader(workspaceHeader)
+		if value == "" {
+			c.Next()
+			return
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			c.AbortWithStatusJSON(400, gin.H{"error": "invalid " + workspaceHeader + " header"})
+			return
+		}
+		userId, err := h.GetUserId(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := h.services.IWorkspaceService.Role(id, userId); err != nil {
+			newErrorResponse(c, err)
+			return
+		}
+		c.Set("workspace_id", id)
+		c.Next()
+	}
+}
+
+// workspaceId returns the workspace selected by the request, nil for the
+// user's personal tasks.
+func workspaceId(c *gin.Context) *int {
+	id, ok := c.Get("workspace_id")
+	if !ok {
+		return nil
+	}
+	idInt := id.(int)
+	return &idInt
+}
+
+// AllWorkspaces godoc
+// @Summary Get the user's workspaces
+// @Description Retrieves the workspaces the user belongs to, with the user's role in each
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}{"workspaces": []Workspace}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /workspaces [get]
+func (h *Handler) AllWorkspaces(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	workspaces, err := h.services.IWorkspaceService.Get(userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"workspaces": workspaces})
+}
+
+// PostWorkspace godoc
+// @Summary Create a workspace
+// @Description Creates a workspace with the user as its owner. Send its ID in the X-Workspace-Id header to work on its tasks
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param request body dtos.WorkspaceForm true "Workspace"
+// @Success 200 {object} gin.H{"id": int}
+// @Failure 400 {object} gin.H{"error": string}
+// @Router /workspaces [post]
+func (h *Handler) PostWorkspace(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.WorkspaceForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := h.services.IWorkspaceService.Create(userId, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"id": id})
+}
+
+// PutWorkspace godoc
+// @Summary Rename a workspace
+// @Description Renames a workspace. Requires the admin or owner role
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param id path int true "Workspace ID"
+// @Param request body dtos.WorkspaceForm true "Workspace"
+// @Success 200 {string} string "Workspace updated message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /workspaces/{id} [put]
+func (h *Handler) PutWorkspace(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.WorkspaceForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IWorkspaceService.Update(id, userId, request); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Workspace was updated")
+}
+
+// DeleteWorkspace godoc
+// @Summary Delete a workspace
+// @Description Deletes a workspace together with all its tasks. Requires the owner role
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param id path int true "Workspace ID"
+// @Success 200 {string} string "Workspace deleted message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /workspaces/{id} [delete]
+func (h *Handler) DeleteWorkspace(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IWorkspaceService.Delete(id, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Workspace was deleted")
+}
+
+// WorkspaceMembers godoc
+// @Summary Get the members of a workspace
+// @Description Retrieves the members of a workspace with their roles
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param id path int true "Workspace ID"
+// @Success 200 {object} map[string]interface{}{"members": []Member}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /workspaces/{id}/members [get]
+func (h *Handler) WorkspaceMembers(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	members, err := h.services.IWorkspaceService.GetMembers(id, userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"members": members})
+}
+
+// PutMemberRole godoc
+// @Summary Change the role of a member
+// @Description Sets a member's role to owner, admin, member or guest. Admins and owners cannot grant more than their own role; the last owner cannot be demoted
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param id path int true "Workspace ID"
+// @Param userId path int true "Member user ID"
+// @Param request body dtos.MemberRole true "Role"
+// @Success 200 {string} string "Member updated message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /workspaces/{id}/members/{userId} [put]
+func (h *Handler) PutMemberRole(c *gin.Context) {
+	userId, id, memberId, ok := h.memberParams(c)
+	if !ok {
+		return
+	}
+	var request dtos.MemberRole
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IWorkspaceService.SetMemberRole(id, memberId, userId, request.Role); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Member was updated")
+}
+
+// DeleteMember godoc
+// @Summary Remove a member from a workspace
+// @Description Removes a member, or lets the user leave when it is their own ID. The last owner cannot leave. Tasks created by the member stay in the workspace
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param id path int true "Workspace ID"
+// @Param userId path int true "Member user ID"
+// @Success 200 {string} string "Member removed message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /workspaces/{id}/members/{userId} [delete]
+func (h *Handler) DeleteMember(c *gin.Context) {
+	userId, id, memberId, ok := h.memberParams(c)
+	if !ok {
+		return
+	}
+	if err := h.services.IWorkspaceService.RemoveMember(id, memberId, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Member was removed")
+}
+
+// PostInvitation godoc
+// @Summary Invite a user to a workspace
+// @Description Invites a user by username with a role no higher than the inviter's. Requires the admin or owner role
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param id path int true "Workspace ID"
+// @Param request body dtos.InvitationForm true "Invitation"
+// @Success 200 {object} gin.H{"id": int}
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 403 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /workspaces/{id}/invitations [post]
+func (h *Handler) PostInvitation(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var request dtos.InvitationForm
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	invitationId, err := h.services.IWorkspaceService.Invite(id, userId, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"id": invitationId})
+}
+
+// AllInvitations godoc
+// @Summary Get the user's pending invitations
+// @Description Retrieves the workspace invitations waiting for the user's answer
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}{"invitations": []Invitation}
+// @Failure 500 {object} gin.H{"error": string}
+// @Router /invitations [get]
+func (h *Handler) AllInvitations(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invitations, err := h.services.IWorkspaceService.GetInvitations(userId)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"invitations": invitations})
+}
+
+// AcceptInvitation godoc
+// @Summary Accept an invitation
+// @Description Joins the workspace with the invited role
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param id path int true "Invitation ID"
+// @Success 200 {string} string "Invitation accepted message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /invitations/{id}/accept [post]
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IWorkspaceService.AcceptInvitation(id, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Invitation was accepted")
+}
+
+// DeclineInvitation godoc
+// @Summary Decline an invitation
+// @Description Declines a pending workspace invitation
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param id path int true "Invitation ID"
+// @Success 200 {string} string "Invitation declined message"
+// @Failure 400 {object} gin.H{"error": string}
+// @Failure 404 {object} gin.H{"error": string}
+// @Router /invitations/{id}/decline [post]
+func (h *Handler) DeclineInvitation(c *gin.Context) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.services.IWorkspaceService.DeclineInvitation(id, userId); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(200, "Invitation was declined")
+}
+
+func (h *Handler) memberParams(c *gin.Context) (userId, workspaceId, memberId int, ok bool) {
+	userId, err := h.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if workspaceId, err = strconv.Atoi(c.Param("id")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	if memberId, err = strconv.Atoi(c.Param("userId")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	return userId, workspaceId, memberId, true
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Actions recorded in the assignment history of a task.
+const (
+	AssignmentAssigned   = "assigned"
+	AssignmentUnassigned = "unassigned"
+)
+
+// Assignee is a user responsible for a task, with who assigned them and when.
+// AssignedBy is nil once that user was deleted.
+type Assignee struct {
+	UserId     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	AssignedBy *string   `json:"assigned_by"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+// AssignmentChange records a user being assigned to or unassigned from a task.
+type AssignmentChange struct {
+	UserId    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	Action    string    `json:"action"`
+	ChangedBy *string   `json:"changed_by"`
+	CreateAt  time.Time `json:"create_at"`
+}
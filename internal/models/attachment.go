@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Attachment describes a file attached to a task. The content itself lives
+// in the blob store.
+type Attachment struct {
+	ID          int       `json:"id"`
+	TaskId      int       `json:"task_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreateAt    time.Time `json:"create_at"`
+}
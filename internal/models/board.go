@@ -0,0 +1,18 @@
+package models
+
+type BoardColumn struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+	IsDone   bool   `json:"is_done"`
+}
+
+// BoardLane is a board column with its tasks in rank order.
+type BoardLane struct {
+	BoardColumn
+	Tasks []Task `json:"tasks"`
+}
+
+type Board struct {
+	Columns []BoardLane `json:"columns"`
+}
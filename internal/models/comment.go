@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Comment is a markdown note on a task. EditedAt is set once the body has
+// been changed; the replaced bodies are kept as CommentVersions.
+type Comment struct {
+	ID       int        `json:"id"`
+	TaskId   int        `json:"task_id"`
+	Author   string     `json:"author"`
+	Body     string     `json:"body"`
+	CreateAt time.Time  `json:"create_at"`
+	EditedAt *time.Time `json:"edited_at"`
+}
+
+// CommentVersion is a former body of a comment and the time it was written.
+type CommentVersion struct {
+	Body     string    `json:"body"`
+	CreateAt time.Time `json:"create_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions recorded in the history of a task.
+const (
+	TaskCreated  = "created"
+	TaskUpdated  = "updated"
+	TaskDeleted  = "deleted"
+	TaskRestored = "restored"
+	TaskPurged   = "purged"
+)
+
+// FieldChange is the JSON value of a task field before and after a change.
+type FieldChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// TaskChange is an entry of the history of a task. Changes is keyed by the
+// JSON name of the changed field. Actor is nil for changes made by the
+// tracker itself and once the user was deleted.
+type TaskChange struct {
+	ID       int                    `json:"id"`
+	TaskId   int                    `json:"task_id"`
+	Actor    *string                `json:"actor"`
+	Action   string                 `json:"action"`
+	Changes  map[string]FieldChange `json:"changes"`
+	CreateAt time.Time              `json:"create_at"`
+}
+
+// ActivityPage is a page of a user's changes, most recent first.
+type ActivityPage struct {
+	Changes    []TaskChange `json:"changes"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
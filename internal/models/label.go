@@ -0,0 +1,7 @@
+package models
+
+type Label struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type Project struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Color       string    `json:"color"`
+	Archived    bool      `json:"archived"`
+	CreateAt    time.Time `json:"create_at"`
+}
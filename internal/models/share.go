@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Roles a task or project can be shared with. Viewers only read; editors may
+// also change tasks, comment and attach files.
+const (
+	ShareViewer = "viewer"
+	ShareEditor = "editor"
+)
+
+// Share grants another user access to a task or to every task of a project.
+type Share struct {
+	UserId   int       `json:"user_id"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	CreateAt time.Time `json:"create_at"`
+}
+
+// SharedTask is a task of another user shared directly or through its
+// project, with the strongest role granted.
+type SharedTask struct {
+	Task
+	Owner string `json:"owner"`
+	Role  string `json:"role"`
+}
@@ -1,9 +1,69 @@
 package models
 
+import "time"
+
 type Task struct {
-	ID          int    `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"desc"`
-	IsCompleted bool   `json:"is_completed"`
-	CreateAt    string `json:"create_at"`
+	ID           int         `json:"id"`
+	Title        string      `json:"title"`
+	Description  string      `json:"desc"`
+	IsCompleted  bool        `json:"is_completed"`
+	CreateAt     time.Time   `json:"create_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	CompletedAt  *time.Time  `json:"completed_at"`
+	StartAt      *time.Time  `json:"start_at"`
+	DueAt        *time.Time  `json:"due_at"`
+	ProjectId    *int        `json:"project_id"`
+	ParentTaskId *int        `json:"parent_task_id"`
+	WorkspaceId  *int        `json:"workspace_id"`
+	Recurrence   *Recurrence `json:"recurrence"`
+	Status       *TaskStatus `json:"status"`
+	Priority     Priority    `json:"priority"`
+	Labels       []Label     `json:"labels"`
+	Assignees    []Assignee  `json:"assignees"`
+	CommentCount int         `json:"comment_count"`
+}
+
+// TrashedTask is a deleted task waiting in the trash to be restored or
+// purged.
+type TrashedTask struct {
+	Task
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Recurrence is an RFC 5545 RRULE evaluated in the given IANA time zone and
+// anchored at the task's due date.
+type Recurrence struct {
+	Rule     string `json:"rule"`
+	TimeZone string `json:"tz"`
+}
+
+// TaskPage is a page of the task list. ViewId names the saved view the list
+// was filtered by, if any.
+type TaskPage struct {
+	Tasks      []Task `json:"tasks"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+	ViewId     *int   `json:"view_id,omitempty"`
+}
+
+// TaskNode is a task together with its subtasks. Progress is the percentage
+// of completed descendants.
+type TaskNode struct {
+	Task
+	Progress int         `json:"progress"`
+	Subtasks []*TaskNode `json:"subtasks"`
+}
+
+// SearchResult is a task matching a full-text search. Rank orders the
+// results; the snippets are HTML with the matches wrapped in <mark> tags.
+type SearchResult struct {
+	Task
+	Rank               float64 `json:"rank"`
+	TitleSnippet       string  `json:"title_snippet"`
+	DescriptionSnippet string  `json:"desc_snippet"`
+}
+
+type SearchPage struct {
+	Results []SearchResult `json:"results"`
+	Total   int            `json:"total"`
 }
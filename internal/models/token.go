@@ -0,0 +1,57 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Scopes an API token can be granted. A write scope does not include the
+// read scope of the same resource.
+const (
+	ScopeTasksRead       = "tasks:read"
+	ScopeTasksWrite      = "tasks:write"
+	ScopeProjectsRead    = "projects:read"
+	ScopeProjectsWrite   = "projects:write"
+	ScopeLabelsRead      = "labels:read"
+	ScopeLabelsWrite     = "labels:write"
+	ScopeViewsRead       = "views:read"
+	ScopeViewsWrite      = "views:write"
+	ScopeBoardRead       = "board:read"
+	ScopeBoardWrite      = "board:write"
+	ScopeWorkspacesRead  = "workspaces:read"
+	ScopeWorkspacesWrite = "workspaces:write"
+	ScopeTokensRead      = "tokens:read"
+	ScopeTokensWrite     = "tokens:write"
+)
+
+// ApiTokenScopes lists the scopes an API token can be granted. Managing API
+// tokens is left to signed-in users, so that a token cannot mint a
+// broader one.
+var ApiTokenScopes = []string{
+	ScopeTasksRead, ScopeTasksWrite,
+	ScopeProjectsRead, ScopeProjectsWrite,
+	ScopeLabelsRead, ScopeLabelsWrite,
+	ScopeViewsRead, ScopeViewsWrite,
+	ScopeBoardRead, ScopeBoardWrite,
+	ScopeWorkspacesRead, ScopeWorkspacesWrite,
+}
+
+// SessionScopes lists the scopes of a signed-in user: all of them.
+var SessionScopes = slices.Concat(ApiTokenScopes, []string{ScopeTokensRead, ScopeTokensWrite})
+
+// ApiToken is a long-lived token a user creates for scripts and other
+// services. Only a hash of its secret is kept.
+type ApiToken struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreateAt   time.Time  `json:"create_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// NewApiToken is a token just created, the only time its secret is shown.
+type NewApiToken struct {
+	ApiToken
+	Token string `json:"token"`
+}
@@ -1,7 +1,26 @@
 package models
 
+import "time"
+
 type User struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
+
+// Tokens is what a sign-in or refresh hands out: a short-lived access token
+// and the refresh token that replaces it, valid once.
+type Tokens struct {
+	AccessToken  string    `json:"token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+}
+
+// Principal is who a request is made for: a user signed in to a session, or
+// a user's API token, which is limited to its scopes.
+type Principal struct {
+	UserId     int
+	SessionId  int
+	ApiTokenId int
+	Scopes     []string
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// SavedView is a named task list filter of a user. The default view applies
+// when the task list is requested without any criteria.
+type SavedView struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Filter    ViewFilter `json:"filter"`
+	Sort      string     `json:"sort"`
+	Order     string     `json:"order"`
+	IsDefault bool       `json:"is_default"`
+	CreateAt  time.Time  `json:"create_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ViewFilter holds the criteria of a saved view. The fields mean the same as
+// the query parameters of the task list they are named after.
+type ViewFilter struct {
+	Completed    *bool      `json:"completed,omitempty"`
+	Title        string     `json:"title,omitempty"`
+	Description  string     `json:"desc,omitempty"`
+	CreatedFrom  *time.Time `json:"created_from,omitempty"`
+	CreatedTo    *time.Time `json:"created_to,omitempty"`
+	Labels       []string   `json:"label,omitempty"`
+	ProjectId    *int       `json:"project_id,omitempty"`
+	Inbox        bool       `json:"inbox,omitempty"`
+	ParentId     *int       `json:"parent_id,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Priority     string     `json:"priority,omitempty"`
+	AssignedToMe bool       `json:"assigned_to_me,omitempty"`
+	CreatedByMe  bool       `json:"created_by_me,omitempty"`
+	Unassigned   bool       `json:"unassigned,omitempty"`
+	Query        string     `json:"q,omitempty"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type Priority int16
+
+const (
+	PriorityLow Priority = iota
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = []string{"low", "medium", "high", "urgent"}
+
+func ParsePriority(s string) (Priority, error) {
+	for i, name := range priorityNames {
+		if name == s {
+			return Priority(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority %q", s)
+}
+
+func (p Priority) String() string {
+	if p < 0 || int(p) >= len(priorityNames) {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParsePriority(s)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+type TaskStatus struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type WorkflowStatus struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Position   int    `json:"position"`
+	IsTerminal bool   `json:"is_terminal"`
+}
+
+type Transition struct {
+	From int `json:"from_status_id"`
+	To   int `json:"to_status_id"`
+}
+
+// Workflow is the ordered set of statuses a task can be in and the allowed
+// moves between them. ProjectId is nil for the user's default workflow.
+type Workflow struct {
+	ProjectId   *int             `json:"project_id"`
+	Statuses    []WorkflowStatus `json:"statuses"`
+	Transitions []Transition     `json:"transitions"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Workspace roles, strongest first. Owners and admins manage the workspace
+// and every task in it, members create and edit tasks, guests only read.
+const (
+	WorkspaceOwner  = "owner"
+	WorkspaceAdmin  = "admin"
+	WorkspaceMember = "member"
+	WorkspaceGuest  = "guest"
+)
+
+// Invitation states.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+)
+
+// Workspace is a shared space for the tasks of a team. Role is the role of
+// the requesting user.
+type Workspace struct {
+	ID       int       `json:"id"`
+	Name     string    `json:"name"`
+	Role     string    `json:"role"`
+	CreateAt time.Time `json:"create_at"`
+}
+
+// Member is a user belonging to a workspace.
+type Member struct {
+	UserId   int       `json:"user_id"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	CreateAt time.Time `json:"create_at"`
+}
+
+// Invitation asks a user to join a workspace with a role.
+type Invitation struct {
+	ID          int       `json:"id"`
+	WorkspaceId int       `json:"workspace_id"`
+	Workspace   string    `json:"workspace"`
+	Username    string    `json:"username"`
+	InvitedBy   *string   `json:"invited_by"`
+	Role        string    `json:"role"`
+	Status      string    `json:"status"`
+	CreateAt    time.Time `json:"create_at"`
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+)
+
+type ApiTokenService struct {
+	db *sql.DB
+}
+
+func NewApiTokenService(db *sql.DB) *ApiTokenService {
+	return &ApiTokenService{db: db}
+}
+
+// ApiTokenPrefix starts every API token, which tells them apart from access
+// tokens in the Authorization header.
+const ApiTokenPrefix = "trk_"
+
+const maxApiTokenNameLength = 255
+
+const (
+	apiTokenColumns = `id, name, scopes, create_at, expires_at, last_used_at`
+	getApiTokens    = `SELECT ` + apiTokenColumns + ` FROM api_tokens WHERE user_id = $1 AND revoked_at IS NULL ORDER BY id`
+	createApiToken  = `INSERT INTO api_tokens (user_id, name, token_hash, scopes, create_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	revokeApiToken = `UPDATE api_tokens SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+	useApiToken    = `UPDATE api_tokens SET last_used_at = $1
+		WHERE token_hash = $2 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $1)
+		RETURNING id, user_id, scopes`
+)
+
+// Scopes are stored space separated, as OAuth writes them.
+func joinScopes(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+func splitScopes(scopes string) []string {
+	return strings.Fields(scopes)
+}
+
+// validateApiToken normalizes a token form: the name is trimmed and the
+// scopes sorted without duplicates.
+func validateApiToken(form dtos.ApiTokenForm, now time.Time) (dtos.ApiTokenForm, error) {
+	form.Name = strings.TrimSpace(form.Name)
+	if form.Name == "" {
+		return form, fmt.Errorf("%w: token name is required", ErrInvalidInput)
+	}
+	if len(form.Name) > maxApiTokenNameLength {
+		return form, fmt.Errorf("%w: token name is longer than %d characters", ErrInvalidInput, maxApiTokenNameLength)
+	}
+	if len(form.Scopes) == 0 {
+		return form, fmt.Errorf("%w: a token needs at least one scope", ErrInvalidInput)
+	}
+	for _, scope := range form.Scopes {
+		if !slices.Contains(models.ApiTokenScopes, scope) {
+			return form, fmt.Errorf("%w: unknown scope %q", ErrInvalidInput, scope)
+		}
+	}
+	form.Scopes = slices.Compact(slices.Sorted(slices.Values(form.Scopes)))
+	if form.ExpiresAt != nil && !form.ExpiresAt.After(now) {
+		return form, fmt.Errorf("%w: expires_at is in the past", ErrInvalidInput)
+	}
+	return form, nil
+}
+
+func scanApiToken(row rowScanner) (models.ApiToken, error) {
+	var token models.ApiToken
+	var scopes string
+	err := row.Scan(&token.ID, &token.Name, &scopes, &token.CreateAt, &token.ExpiresAt, &token.LastUsedAt)
+	token.Scopes = splitScopes(scopes)
+	return token, err
+}
+
+// Get lists the API tokens of a user that were not revoked, expired ones
+// included.
+func (s *ApiTokenService) Get(userId int) ([]models.ApiToken, error) {
+	tokens := []models.ApiToken{}
+	rows, err := s.db.Query(getApiTokens, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		token, err := scanApiToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// Create makes an API token. The returned secret is not stored and cannot be
+// shown again.
+func (s *ApiTokenService) Create(userId int, form dtos.ApiTokenForm) (*models.NewApiToken, error) {
+	now := time.Now()
+	form, err := validateApiToken(form, now)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	token := models.NewApiToken{
+		ApiToken: models.ApiToken{Name: form.Name, Scopes: form.Scopes, CreateAt: now, ExpiresAt: form.ExpiresAt},
+		Token:    ApiTokenPrefix + secret,
+	}
+	err = s.db.QueryRow(createApiToken, userId, form.Name, hashToken(token.Token), joinScopes(form.Scopes), now, form.ExpiresAt).Scan(&token.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Revoke makes an API token of the user unusable at once.
+func (s *ApiTokenService) Revoke(tokenId, userId int) error {
+	res, err := s.db.Exec(revokeApiToken, time.Now(), tokenId, userId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// ParseApiToken checks an API token and records that it was used.
+func (s *ApiTokenService) ParseApiToken(token string) (*models.Principal, error) {
+	var principal models.Principal
+	var scopes string
+	err := s.db.QueryRow(useApiToken, time.Now(), hashToken(token)).Scan(&principal.ApiTokenId, &principal.UserId, &scopes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: unknown, expired or revoked API token", ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+	principal.Scopes = splitScopes(scopes)
+	return &principal, nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"trackerApp/internal/models"
+)
+
+type AssigneeService struct {
+	db *sql.DB
+}
+
+func NewAssigneeService(db *sql.DB) *AssigneeService {
+	return &AssigneeService{db: db}
+}
+
+const (
+	createAssignee = `INSERT INTO task_assignees (task_id, user_id, assigned_by, assigned_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (task_id, user_id) DO NOTHING`
+	deleteAssignee         = `DELETE FROM task_assignees WHERE task_id = $1 AND user_id = $2`
+	createAssignmentChange = `INSERT INTO task_assignment_changes (task_id, user_id, action, changed_by, create_at) VALUES ($1, $2, $3, $4, $5)`
+	getAssignmentChanges   = `SELECT c.user_id, u.username, c.action, b.username, c.create_at FROM task_assignment_changes c
+		JOIN users u ON u.id = c.user_id LEFT JOIN users b ON b.id = c.changed_by
+		WHERE c.task_id = $1 ORDER BY c.create_at, c.id`
+)
+
+func (s *AssigneeService) Get(taskId, userId int) ([]models.Assignee, error) {
+	if _, err := authorizeTask(s.db, taskId, userId, accessView); err != nil {
+		return nil, err
+	}
+	tasks := []models.Task{{ID: taskId}}
+	if err := loadAssignees(s.db, tasks); err != nil {
+		return nil, err
+	}
+	return tasks[0].Assignees, nil
+}
+
+// Assign makes a user responsible for a task. Only users who can see the
+// task may be assigned; assigning someone twice changes nothing.
+func (s *AssigneeService) Assign(taskId, assigneeId, userId int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := authorizeTask(tx, taskId, userId, accessEdit); err != nil {
+		return err
+	}
+	if _, err := authorizeTask(tx, taskId, assigneeId, accessView); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("%w: user %d has no access to task %d", ErrInvalidInput, assigneeId, taskId)
+		}
+		return err
+	}
+	now := time.Now()
+	res, err := tx.Exec(createAssignee, taskId, assigneeId, userId, now)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(createAssignmentChange, taskId, assigneeId, models.AssignmentAssigned, userId, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Unassign removes a user from a task. Editors unassign anyone, other users
+// only themselves.
+func (s *AssigneeService) Unassign(taskId, assigneeId, userId int) error {
+	level := accessEdit
+	if assigneeId == userId {
+		level = accessView
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := authorizeTask(tx, taskId, userId, level); err != nil {
+		return err
+	}
+	res, err := tx.Exec(deleteAssignee, taskId, assigneeId)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(res); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(createAssignmentChange, taskId, assigneeId, models.AssignmentUnassigned, userId, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetChanges lists who was assigned to or unassigned from a task, by whom
+// and when, oldest first.
+func (s *AssigneeService) GetChanges(taskId, userId int) ([]models.AssignmentChange, error) {
+	if _, err := authorizeTask(s.db, taskId, userId, accessView); err != nil {
+		return nil, err
+	}
+	changes := []models.AssignmentChange{}
+	rows, err := s.db.Query(getAssignmentChanges, taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change models.AssignmentChange
+		if err := rows.Scan(&change.UserId, &change.Username, &change.Action, &change.ChangedBy, &change.CreateAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+	"trackerApp/pkg/storage"
+)
+
+// DefaultMaxAttachmentSize is the upload limit in bytes when the Config sets none.
+const DefaultMaxAttachmentSize = 10 << 20
+
+// DefaultAttachmentTypes are the accepted media types when the Config lists none.
+var DefaultAttachmentTypes = []string{"image/*", "text/plain", "application/pdf", "application/zip", "application/x-gzip"}
+
+const maxFileNameLength = 255
+
+type AttachmentService struct {
+	db      *sql.DB
+	store   storage.Store
+	maxSize int64
+	types   []string
+}
+
+func NewAttachmentService(db *sql.DB, store storage.Store, maxSize int64, types []string) *AttachmentService {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxAttachmentSize
+	}
+	if len(types) == 0 {
+		types = DefaultAttachmentTypes
+	}
+	return &AttachmentService{db: db, store: store, maxSize: maxSize, types: types}
+}
+
+// MaxSize is the largest file size in bytes that Create accepts.
+func (s *AttachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+const attachmentColumns = `a.id, a.task_id, a.file_name, a.content_type, a.size, a.create_at`
+
+const (
+	getAttachments = `SELECT ` + attachmentColumns + ` FROM attachments a WHERE a.task_id = $1 ORDER BY a.create_at, a.id`
+	getAttachment  = `SELECT ` + attachmentColumns + `, a.storage_key FROM attachments a JOIN tasks t ON t.id = a.task_id
+		WHERE a.id = $1 AND a.task_id = $2 AND t.user_id = $3`
+	createAttachment = `INSERT INTO attachments (task_id, user_id, file_name, content_type, size, storage_key, create_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	deleteAttachmentById = `DELETE FROM attachments a USING tasks t
+		WHERE a.id = $1 AND a.task_id = $2 AND t.id = a.task_id AND t.user_id = $3 RETURNING a.storage_key`
+	getTaskBlobKeys = `WITH RECURSIVE subtree AS (
+			SELECT id FROM tasks WHERE id = $1 AND user_id = $2
+			UNION
+			SELECT t.id FROM tasks t JOIN subtree s ON t.parent_task_id = s.id
+		)
+		SELECT storage_key FROM attachments WHERE task_id IN (SELECT id FROM subtree)`
+)
+
+// getBlobKeys lists the storage keys of the attachments matched by query.
+// Callers read them before deleting tasks, since the rows go with the tasks.
+func getBlobKeys(q querier, query string, args ...any) ([]string, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// deleteBlobs removes the contents of attachments whose rows are already
+// gone. A failure only leaves an orphaned blob behind, so it is logged
+// instead of failing the request.
+func deleteBlobs(store storage.Store, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(context.Background(), key); err != nil && !errors.Is(err, storage.ErrNotExist) {
+			log.Printf("attachments: delete blob %s: %v", key, err)
+		}
+	}
+}
+
+func newBlobKey(taskId int) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tasks/%d/%s", taskId, hex.EncodeToString(b)), nil
+}
+
+// allowsType reports whether a media type matches one of the allowed types;
+// "image/*" matches every image type.
+func allowsType(allowed []string, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range allowed {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func validateFileName(name string) (string, error) {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		return "", fmt.Errorf("%w: file name is required", ErrInvalidInput)
+	}
+	if len(name) > maxFileNameLength {
+		return "", fmt.Errorf("%w: file name is longer than %d characters", ErrInvalidInput, maxFileNameLength)
+	}
+	return name, nil
+}
+
+func (s *AttachmentService) Get(taskId, userId int) ([]models.Attachment, error) {
+	if _, err := authorizeTask(s.db, taskId, userId, accessView); err != nil {
+		return nil, err
+	}
+	attachments := []models.Attachment{}
+	rows, err := s.db.Query(getAttachments, taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Attachment
+		if err := rows.Scan(&a.ID, &a.TaskId, &a.FileName, &a.ContentType, &a.Size, &a.CreateAt); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// Create stores an uploaded file. The content type is sniffed from the
+// first bytes of the file rather than trusted from the client.
+func (s *AttachmentService) Create(taskId, userId int, upload dtos.Upload) (int, error) {
+	fileName, err := validateFileName(upload.FileName)
+	if err != nil {
+		return 0, err
+	}
+	if upload.Size <= 0 {
+		return 0, fmt.Errorf("%w: file is empty", ErrInvalidInput)
+	}
+	if upload.Size > s.maxSize {
+		return 0, fmt.Errorf("%w: file is larger than %d bytes", ErrInvalidInput, s.maxSize)
+	}
+	if _, err := authorizeTask(s.db, taskId, userId, accessEdit); err != nil {
+		return 0, err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !allowsType(s.types, contentType) {
+		return 0, fmt.Errorf("%w: files of type %s are not allowed", ErrInvalidInput, contentType)
+	}
+
+	key, err := newBlobKey(taskId)
+	if err != nil {
+		return 0, err
+	}
+	content := io.MultiReader(bytes.NewReader(head), upload.Content)
+	if err := s.store.Put(context.Background(), key, content, upload.Size, contentType); err != nil {
+		return 0, err
+	}
+	var id int
+	err = s.db.QueryRow(createAttachment, taskId, userId, fileName, contentType, upload.Size, key, time.Now()).Scan(&id)
+	if err != nil {
+		deleteBlobs(s.store, []string{key})
+		return 0, err
+	}
+	return id, nil
+}
+
+// Open returns an attachment with a reader for its content. The caller
+// closes the reader.
+func (s *AttachmentService) Open(taskId, attachmentId, userId int) (*models.Attachment, io.ReadCloser, error) {
+	ownerId, err := authorizeTask(s.db, taskId, userId, accessView)
+	if err != nil {
+		return nil, nil, err
+	}
+	var a models.Attachment
+	var key string
+	err = s.db.QueryRow(getAttachment, attachmentId, taskId, ownerId).
+		Scan(&a.ID, &a.TaskId, &a.FileName, &a.ContentType, &a.Size, &a.CreateAt, &key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.store.Get(context.Background(), key)
+	if errors.Is(err, storage.ErrNotExist) {
+		return nil, nil, fmt.Errorf("%w: content of attachment %d is missing", ErrNotFound, attachmentId)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return &a, content, nil
+}
+
+func (s *AttachmentService) Delete(taskId, attachmentId, userId int) error {
+	ownerId, err := authorizeTask(s.db, taskId, userId, accessEdit)
+	if err != nil {
+		return err
+	}
+	var key string
+	err = s.db.QueryRow(deleteAttachmentById, attachmentId, taskId, ownerId).Scan(&key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	deleteBlobs(s.store, []string{key})
+	return nil
+}
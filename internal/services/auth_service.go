@@ -1,34 +1,65 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 	"trackerApp/internal/models"
 	"trackerApp/internal/services/dtos"
+	"trackerApp/pkg/signing"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
-	db *sql.DB
+	db         *sql.DB
+	keys       *signing.KeySet
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
-func NewAuthService(db *sql.DB) *AuthService {
-	return &AuthService{db: db}
+// DefaultAccessTokenTTL and DefaultRefreshTokenTTL are the token lifetimes
+// when the Config sets none.
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+func NewAuthService(db *sql.DB, keys *signing.KeySet, accessTTL, refreshTTL time.Duration) *AuthService {
+	if accessTTL <= 0 {
+		accessTTL = DefaultAccessTokenTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = DefaultRefreshTokenTTL
+	}
+	return &AuthService{db: db, keys: keys, accessTTL: accessTTL, refreshTTL: refreshTTL}
 }
 
 const (
 	getByUsername = `SELECT id,username,password FROM users WHERE username=$1`
 	createUser    = `INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id`
-)
 
-const secretKey = "secret key"
+	createSession      = `INSERT INTO sessions (user_id, create_at) VALUES ($1, $2) RETURNING id`
+	revokeSession      = `UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+	revokeSessionById  = `UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`
+	isSessionActive    = `SELECT EXISTS (SELECT 1 FROM sessions WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL)`
+	createRefreshToken = `INSERT INTO refresh_tokens (session_id, token_hash, create_at, expires_at) VALUES ($1, $2, $3, $4)`
+	getRefreshToken    = `SELECT r.id, r.session_id, r.expires_at, r.used_at, s.revoked_at, u.id, u.username FROM refresh_tokens r
+		JOIN sessions s ON s.id = r.session_id JOIN users u ON u.id = s.user_id WHERE r.token_hash = $1 FOR UPDATE OF r, s`
+	useRefreshToken = `UPDATE refresh_tokens SET used_at = $1 WHERE id = $2`
+)
 
 type CustomClaims struct {
-	UserId   int    `json:"user_id"`
-	Username string `json:"username"`
+	UserId    int    `json:"user_id"`
+	Username  string `json:"username"`
+	SessionId int    `json:"sid"`
+	Scope     string `json:"scope"`
 	jwt.RegisteredClaims
 }
 
@@ -44,46 +75,159 @@ func (s *AuthService) AddUser(form dtos.UserForm) (int, error) {
 	return userId, nil
 }
 
-func (s *AuthService) GenerateJwt(form dtos.UserForm) (string, error) {
+// GenerateJwt signs a user in and starts a session with its first pair of
+// tokens.
+func (s *AuthService) GenerateJwt(form dtos.UserForm) (*models.Tokens, error) {
 	var user models.User
 	if err := s.db.QueryRow(getByUsername, form.Username).Scan(&user.ID, &user.Username, &user.Password); err != nil {
-		return "", err
+		return nil, err
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(form.Password)); err != nil {
-		return "", errors.New("Wrong username or password")
+		return nil, errors.New("Wrong username or password")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
 	}
+	defer tx.Rollback()
 
+	tokens, err := s.startSession(tx, user, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return tokens, tx.Commit()
+}
+
+// startSession starts a session of a signed-in user with its first pair of
+// tokens.
+func (s *AuthService) startSession(tx *sql.Tx, user models.User, now time.Time) (*models.Tokens, error) {
+	var sessionId int
+	if err := tx.QueryRow(createSession, user.ID, now).Scan(&sessionId); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(tx, user, sessionId, now)
+}
+
+// Refresh trades a refresh token for a new pair of tokens. Every refresh
+// token is valid once: presenting a used one again means it was stolen, so
+// the whole session is revoked.
+func (s *AuthService) Refresh(refreshToken string) (*models.Tokens, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var tokenId, sessionId int
+	var expiresAt time.Time
+	var usedAt, revokedAt *time.Time
+	var user models.User
+	err = tx.QueryRow(getRefreshToken, hashToken(refreshToken)).Scan(&tokenId, &sessionId, &expiresAt, &usedAt, &revokedAt, &user.ID, &user.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: unknown refresh token", ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt != nil {
+		return nil, fmt.Errorf("%w: session was revoked", ErrUnauthorized)
+	}
+	now := time.Now()
+	if usedAt != nil {
+		if _, err := tx.Exec(revokeSessionById, now, sessionId); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: refresh token was already used, session was revoked", ErrUnauthorized)
+	}
+	if !now.Before(expiresAt) {
+		return nil, fmt.Errorf("%w: refresh token expired", ErrUnauthorized)
+	}
+	if _, err := tx.Exec(useRefreshToken, now, tokenId); err != nil {
+		return nil, err
+	}
+	tokens, err := s.issueTokens(tx, user, sessionId, now)
+	if err != nil {
+		return nil, err
+	}
+	return tokens, tx.Commit()
+}
+
+// Logout revokes a session. Its access tokens stop working at once, its
+// refresh tokens can no longer be used.
+func (s *AuthService) Logout(principal models.Principal) error {
+	res, err := s.db.Exec(revokeSession, time.Now(), principal.SessionId, principal.UserId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (s *AuthService) issueTokens(tx *sql.Tx, user models.User, sessionId int, now time.Time) (*models.Tokens, error) {
+	expiresAt := now.Add(s.accessTTL)
 	claims := CustomClaims{
 		user.ID,
 		user.Username,
+		sessionId,
+		joinScopes(models.SessionScopes),
 		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(25 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	tokenString, err := token.SignedString([]byte(secretKey))
+	accessToken, err := s.keys.Sign(claims)
 	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(createRefreshToken, sessionId, hashToken(refreshToken), now, now.Add(s.refreshTTL)); err != nil {
+		return nil, err
+	}
+	return &models.Tokens{AccessToken: accessToken, ExpiresAt: expiresAt, RefreshToken: refreshToken}, nil
+}
+
+// newSecret returns a random token for the client to keep. Only its hash is
+// stored.
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	return tokenString, nil
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
-func (s *AuthService) ParseJwt(accessToken string) (int, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return []byte(secretKey), nil
-	})
+// ParseJwt checks an access token and that its session was not revoked.
+func (s *AuthService) ParseJwt(accessToken string) (*models.Principal, error) {
+	token, err := jwt.ParseWithClaims(accessToken, &CustomClaims{}, s.keys.Keyfunc)
 	if err != nil {
-		return 0, err
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
 	}
 	claims, ok := token.Claims.(*CustomClaims)
 	if !ok {
-		return 0, errors.New("token claims are not of type (*CustomClaims)")
+		return nil, errors.New("token claims are not of type (*CustomClaims)")
+	}
+	var active bool
+	if err := s.db.QueryRow(isSessionActive, claims.SessionId, claims.UserId).Scan(&active); err != nil {
+		return nil, err
 	}
-	return claims.UserId, nil
+	if !active {
+		return nil, fmt.Errorf("%w: session was revoked", ErrUnauthorized)
+	}
+	return &models.Principal{UserId: claims.UserId, SessionId: claims.SessionId, Scopes: splitScopes(claims.Scope)}, nil
+}
+
+// JWKS lists the public keys access tokens can be verified with.
+func (s *AuthService) JWKS() signing.JWKS {
+	return s.keys.JWKS()
 }
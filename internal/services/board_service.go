@@ -0,0 +1,474 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+	"trackerApp/pkg/rank"
+)
+
+type BoardService struct {
+	db *sql.DB
+}
+
+func NewBoardService(db *sql.DB) *BoardService {
+	return &BoardService{db: db}
+}
+
+// defaultBoardColumns are created for every user on first use and mirror the
+// is_complete flag.
+var defaultBoardColumns = []dtos.BoardColumnForm{
+	{Name: "Open"},
+	{Name: "Done", IsDone: true},
+}
+
+const maxColumnNameLength = 64
+
+// boardColumnOf is the column of the board of user $1 a task is shown in: its
+// own column if that matches the task's completion state, otherwise the first
+// column that does.
+const boardColumnOf = `COALESCE(
+		(SELECT bc.id FROM board_columns bc WHERE bc.id = tasks.column_id AND bc.user_id = $1 AND bc.is_done = COALESCE(tasks.is_complete, FALSE)),
+		(SELECT bc.id FROM board_columns bc WHERE bc.user_id = $1 AND bc.is_done = COALESCE(tasks.is_complete, FALSE)
+			ORDER BY bc.position, bc.id LIMIT 1))`
+
+// The board of a user shows the tasks in scope, see inTaskScope.
+const (
+	getBoardColumns     = `SELECT id, name, position, is_done FROM board_columns WHERE user_id = $1 ORDER BY position, id`
+	createDefaultColumn = `INSERT INTO board_columns (user_id, name, position, is_done) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, name) DO NOTHING`
+	createColumn      = `INSERT INTO board_columns (user_id, name, position, is_done) VALUES ($1, $2, $3, $4) RETURNING id`
+	updateColumnById  = `UPDATE board_columns SET name=$1, is_done=$2 WHERE id=$3 AND user_id=$4`
+	setColumnPosition = `UPDATE board_columns SET position=$1 WHERE id=$2`
+	deleteColumnById  = `DELETE FROM board_columns WHERE id=$1 AND user_id=$2`
+	getBoardTasks     = `SELECT ` + boardColumnOf + `, ` + taskColumns + ` FROM tasks
+		WHERE ` + inTaskScope + ` AND ($3::int IS NULL OR project_id = $3) AND deleted_at IS NULL ORDER BY rank, id`
+	getCardPlace = `SELECT rank, ` + boardColumnOf + ` FROM tasks WHERE ` + inTaskScope + ` AND id = $3 AND deleted_at IS NULL`
+	getFirstCard = `SELECT rank FROM tasks WHERE ` + inTaskScope + ` AND deleted_at IS NULL AND id <> $3 AND ` + boardColumnOf + ` = $4
+		ORDER BY rank, id LIMIT 1`
+	getNextCard = `SELECT rank FROM tasks WHERE ` + inTaskScope + ` AND deleted_at IS NULL AND id <> $3 AND ` + boardColumnOf + ` = $4
+		AND (rank, id) > ($5, $6) ORDER BY rank, id LIMIT 1`
+	getColumnCards = `SELECT id FROM tasks WHERE ` + inTaskScope + ` AND deleted_at IS NULL AND id <> $3 AND ` + boardColumnOf + ` = $4
+		ORDER BY rank, id`
+	setCardPlace = `UPDATE tasks SET column_id=$1, rank=$2, updated_at=$3 WHERE id=$4`
+	setCardRank  = `UPDATE tasks SET rank=$1 WHERE id=$2`
+	getLastRank  = `SELECT COALESCE(MAX(rank), '') FROM tasks WHERE ` + inTaskScope
+)
+
+// nextRank is the rank of a task appended to the end of the board of a scope.
+func nextRank(q querier, userId int, workspaceId *int) (string, error) {
+	var last string
+	if err := q.QueryRow(getLastRank, userId, workspaceId).Scan(&last); err != nil {
+		return "", err
+	}
+	return rank.After(last), nil
+}
+
+func resolveBoardColumns(q querier, userId int) ([]models.BoardColumn, error) {
+	columns, err := queryBoardColumns(q, userId)
+	if err != nil || len(columns) > 0 {
+		return columns, err
+	}
+	for i, column := range defaultBoardColumns {
+		// concurrent requests may race to create the same columns
+		if _, err := q.Exec(createDefaultColumn, userId, column.Name, i, column.IsDone); err != nil {
+			return nil, err
+		}
+	}
+	return queryBoardColumns(q, userId)
+}
+
+func queryBoardColumns(q querier, userId int) ([]models.BoardColumn, error) {
+	columns := []models.BoardColumn{}
+	rows, err := q.Query(getBoardColumns, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var column models.BoardColumn
+		if err := rows.Scan(&column.ID, &column.Name, &column.Position, &column.IsDone); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+func findColumn(columns []models.BoardColumn, columnId int) *models.BoardColumn {
+	for i := range columns {
+		if columns[i].ID == columnId {
+			return &columns[i]
+		}
+	}
+	return nil
+}
+
+func validateColumn(form dtos.BoardColumnForm) (dtos.BoardColumnForm, error) {
+	form.Name = strings.TrimSpace(form.Name)
+	if form.Name == "" || len(form.Name) > maxColumnNameLength {
+		return form, fmt.Errorf("%w: column names must be 1 to %d characters long", ErrInvalidInput, maxColumnNameLength)
+	}
+	return form, nil
+}
+
+// checkColumnKindRemains keeps at least one open and one done column, so that
+// every task has a column to be shown in.
+func checkColumnKindRemains(columns []models.BoardColumn, column models.BoardColumn) error {
+	for _, other := range columns {
+		if other.ID != column.ID && other.IsDone == column.IsDone {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: the board needs at least one open and one done column", ErrConflict)
+}
+
+// reorderColumns moves a column to the given position and renumbers the rest.
+func reorderColumns(tx *sql.Tx, columns []models.BoardColumn, columnId, position int) error {
+	ids := make([]int, 0, len(columns))
+	for _, column := range columns {
+		if column.ID != columnId {
+			ids = append(ids, column.ID)
+		}
+	}
+	position = max(0, min(position, len(ids)))
+	ids = append(ids[:position], append([]int{columnId}, ids[position:]...)...)
+	for i, id := range ids {
+		if _, err := tx.Exec(setColumnPosition, i, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BoardService) GetColumns(userId int) ([]models.BoardColumn, error) {
+	return resolveBoardColumns(s.db, userId)
+}
+
+func (s *BoardService) CreateColumn(userId int, form dtos.BoardColumnForm) (int, error) {
+	form, err := validateColumn(form)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	columns, err := resolveBoardColumns(tx, userId)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	err = tx.QueryRow(createColumn, userId, form.Name, len(columns), form.IsDone).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, fmt.Errorf("%w: column %q already exists", ErrConflict, form.Name)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if form.Position != nil {
+		columns = append(columns, models.BoardColumn{ID: id})
+		if err := reorderColumns(tx, columns, id, *form.Position); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+func (s *BoardService) UpdateColumn(columnId, userId int, form dtos.BoardColumnForm) error {
+	form, err := validateColumn(form)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	columns, err := resolveBoardColumns(tx, userId)
+	if err != nil {
+		return err
+	}
+	column := findColumn(columns, columnId)
+	if column == nil {
+		return ErrNotFound
+	}
+	if column.IsDone != form.IsDone {
+		if err := checkColumnKindRemains(columns, *column); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(updateColumnById, form.Name, form.IsDone, columnId, userId)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: column %q already exists", ErrConflict, form.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if form.Position != nil {
+		if err := reorderColumns(tx, columns, columnId, *form.Position); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteColumn removes a column; its tasks fall back to the first column
+// matching their completion state.
+func (s *BoardService) DeleteColumn(columnId, userId int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	columns, err := resolveBoardColumns(tx, userId)
+	if err != nil {
+		return err
+	}
+	column := findColumn(columns, columnId)
+	if column == nil {
+		return ErrNotFound
+	}
+	if err := checkColumnKindRemains(columns, *column); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(deleteColumnById, columnId, userId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *BoardService) GetBoard(userId int, workspaceId, projectId *int) (*models.Board, error) {
+	if err := checkTaskScope(s.db, userId, workspaceId); err != nil {
+		return nil, err
+	}
+	columns, err := resolveBoardColumns(s.db, userId)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(getBoardTasks, userId, workspaceId, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	var taskColumnIds []int
+	for rows.Next() {
+		var columnId sql.NullInt64
+		task, err := scanTask(prefixScanner{row: rows, dest: []any{&columnId}})
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+		taskColumnIds = append(taskColumnIds, int(columnId.Int64))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadTaskDetails(s.db, tasks); err != nil {
+		return nil, err
+	}
+
+	board := &models.Board{Columns: make([]models.BoardLane, len(columns))}
+	lanes := make(map[int]*models.BoardLane, len(columns))
+	for i, column := range columns {
+		board.Columns[i] = models.BoardLane{BoardColumn: column, Tasks: []models.Task{}}
+		lanes[column.ID] = &board.Columns[i]
+	}
+	for i, task := range tasks {
+		if lane, ok := lanes[taskColumnIds[i]]; ok {
+			lane.Tasks = append(lane.Tasks, task)
+		}
+	}
+	return board, nil
+}
+
+// MoveCard puts a task into a column right after another task of that column,
+// or at its top. Moving between open and done columns changes the task's
+// status like completing or reopening it would. Normally only the moved task
+// is updated; the column is renumbered only if its ranks have no room left.
+func (s *BoardService) MoveCard(taskId, userId int, move dtos.MoveCard) error {
+	if move.AfterId != nil && *move.AfterId == taskId {
+		return fmt.Errorf("%w: a task cannot be moved after itself", ErrInvalidInput)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	columns, err := resolveBoardColumns(tx, userId)
+	if err != nil {
+		return err
+	}
+	column := findColumn(columns, move.ColumnId)
+	if column == nil {
+		return fmt.Errorf("%w: column %d does not exist", ErrInvalidInput, move.ColumnId)
+	}
+	ownerId, err := authorizeTask(tx, taskId, userId, accessEdit)
+	if err != nil {
+		return err
+	}
+	current, err := scanTask(tx.QueryRow(getTaskForUpdate, taskId, ownerId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	// personal tasks shared with the user are on their owner's board only
+	if current.WorkspaceId == nil && ownerId != userId {
+		return ErrNotFound
+	}
+	scope := current.WorkspaceId
+
+	now := time.Now()
+	if column.IsDone != current.IsCompleted {
+		if err := setCompletion(tx, current, ownerId, userId, column.IsDone, now); err != nil {
+			return err
+		}
+	}
+
+	var lower, upper string
+	var upperErr error
+	if move.AfterId != nil {
+		var afterColumn sql.NullInt64
+		err := tx.QueryRow(getCardPlace, userId, scope, *move.AfterId).Scan(&lower, &afterColumn)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: task %d does not exist", ErrInvalidInput, *move.AfterId)
+		}
+		if err != nil {
+			return err
+		}
+		if !afterColumn.Valid || int(afterColumn.Int64) != column.ID {
+			return fmt.Errorf("%w: task %d is not in column %q", ErrInvalidInput, *move.AfterId, column.Name)
+		}
+		upperErr = tx.QueryRow(getNextCard, userId, scope, taskId, column.ID, lower, *move.AfterId).Scan(&upper)
+	} else {
+		upperErr = tx.QueryRow(getFirstCard, userId, scope, taskId, column.ID).Scan(&upper)
+	}
+	hasUpper := upperErr == nil
+	if upperErr != nil && !errors.Is(upperErr, sql.ErrNoRows) {
+		return upperErr
+	}
+
+	key, err := rank.Between(lower, upper)
+	if err != nil || (move.AfterId != nil && lower == "") || (hasUpper && upper == "") {
+		// unranked or colliding neighbours
+		if key, err = rerankColumn(tx, userId, scope, taskId, column.ID, move.AfterId); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(setCardPlace, column.ID, key, now, taskId); err != nil {
+		return err
+	}
+	moved, err := scanTask(tx.QueryRow(getTaskById, taskId, ownerId))
+	if err != nil {
+		return err
+	}
+	if err := recordTaskChange(tx, taskId, userId, models.TaskUpdated, &current, &moved, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rerankColumn spreads fresh ranks over the tasks of a column, leaving a slot
+// for the moved task, and returns the rank of that slot.
+func rerankColumn(tx *sql.Tx, userId int, workspaceId *int, taskId, columnId int, afterId *int) (string, error) {
+	rows, err := tx.Query(getColumnCards, userId, workspaceId, taskId, columnId)
+	if err != nil {
+		return "", err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return "", err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	position := 0
+	if afterId != nil {
+		for i, id := range ids {
+			if id == *afterId {
+				position = i + 1
+			}
+		}
+	}
+	keys := rank.Spread(len(ids) + 1)
+	for i, id := range ids {
+		key := keys[i]
+		if i >= position {
+			key = keys[i+1]
+		}
+		if _, err := tx.Exec(setCardRank, key, id); err != nil {
+			return "", err
+		}
+	}
+	return keys[position], nil
+}
+
+// setCompletion moves a task into the first status of its workflow matching
+// the done flag, with the same checks and effects as completing or reopening
+// it through TaskService.Update. The caller records the change of the task
+// itself.
+func setCompletion(tx *sql.Tx, task models.Task, ownerId, actorId int, done bool, now time.Time) error {
+	workflow, err := resolveWorkflow(tx, ownerId, task.ProjectId)
+	if err != nil {
+		return err
+	}
+	status, err := nextTaskStatus(workflow, task, nil, done)
+	if err != nil {
+		return err
+	}
+	if done {
+		if err := checkBlockers(tx, task.ID); err != nil {
+			return err
+		}
+		if err := completeSubtasks(tx, task.ID, ownerId, actorId, OpenSubtasksRefuse, now); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(setTaskStatus, status.ID, status.IsTerminal, now, task.ID); err != nil {
+		return err
+	}
+	if done && task.Recurrence != nil {
+		nextId, err := spawnNextOccurrence(tx, workflow, task, ownerId, now)
+		if err != nil || nextId == 0 {
+			return err
+		}
+		next, err := scanTask(tx.QueryRow(getTaskById, nextId, ownerId))
+		if err != nil {
+			return err
+		}
+		return recordTaskChange(tx, nextId, actorId, models.TaskCreated, nil, &next, now)
+	}
+	return nil
+}
+
+// prefixScanner scans leading columns of a row before handing the rest to
+// another scan function such as scanTask.
+type prefixScanner struct {
+	row  rowScanner
+	dest []any
+}
+
+func (p prefixScanner) Scan(dest ...any) error {
+	return p.row.Scan(append(p.dest, dest...)...)
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+	"unicode/utf8"
+)
+
+type CommentService struct {
+	db *sql.DB
+}
+
+func NewCommentService(db *sql.DB) *CommentService {
+	return &CommentService{db: db}
+}
+
+const maxCommentLength = 10000
+
+const (
+	getComments = `SELECT c.id, c.task_id, u.username, c.body, c.create_at, c.edited_at FROM comments c
+		JOIN users u ON u.id = c.user_id WHERE c.task_id = $1 ORDER BY c.create_at, c.id`
+	createComment     = `INSERT INTO comments (task_id, user_id, body, create_at) VALUES ($1, $2, $3, $4) RETURNING id`
+	getOwnComment     = `SELECT body, COALESCE(edited_at, create_at) FROM comments WHERE id = $1 AND task_id = $2 AND user_id = $3 FOR UPDATE`
+	updateCommentById = `UPDATE comments SET body=$1, edited_at=$2 WHERE id=$3`
+	deleteCommentById = `DELETE FROM comments WHERE id=$1 AND task_id=$2 AND user_id=$3`
+	createVersion     = `INSERT INTO comment_versions (comment_id, body, create_at) VALUES ($1, $2, $3)`
+	getVersions       = `SELECT v.body, v.create_at FROM comment_versions v JOIN comments c ON c.id = v.comment_id
+		WHERE v.comment_id = $1 AND c.task_id = $2 ORDER BY v.create_at, v.id`
+	commentExists = `SELECT EXISTS (SELECT 1 FROM comments WHERE id = $1 AND task_id = $2)`
+)
+
+func validateComment(form dtos.CommentForm) (dtos.CommentForm, error) {
+	if strings.TrimSpace(form.Body) == "" {
+		return form, fmt.Errorf("%w: comment body is required", ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(form.Body) > maxCommentLength {
+		return form, fmt.Errorf("%w: comment is longer than %d characters", ErrInvalidInput, maxCommentLength)
+	}
+	return form, nil
+}
+
+func (s *CommentService) Get(taskId, userId int) ([]models.Comment, error) {
+	if _, err := authorizeTask(s.db, taskId, userId, accessView); err != nil {
+		return nil, err
+	}
+	comments := []models.Comment{}
+	rows, err := s.db.Query(getComments, taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(&comment.ID, &comment.TaskId, &comment.Author, &comment.Body, &comment.CreateAt, &comment.EditedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+func (s *CommentService) Create(taskId, userId int, form dtos.CommentForm) (int, error) {
+	form, err := validateComment(form)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := authorizeTask(s.db, taskId, userId, accessEdit); err != nil {
+		return 0, err
+	}
+	var id int
+	if err := s.db.QueryRow(createComment, taskId, userId, form.Body, time.Now()).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Update replaces the body of one of the user's comments and keeps the
+// previous body as a version.
+func (s *CommentService) Update(taskId, commentId, userId int, form dtos.CommentForm) error {
+	form, err := validateComment(form)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := authorizeTask(tx, taskId, userId, accessEdit); err != nil {
+		return err
+	}
+	var body string
+	var writtenAt time.Time
+	err = tx.QueryRow(getOwnComment, commentId, taskId, userId).Scan(&body, &writtenAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if body == form.Body {
+		return nil
+	}
+	if _, err := tx.Exec(createVersion, commentId, body, writtenAt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(updateCommentById, form.Body, time.Now(), commentId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *CommentService) Delete(taskId, commentId, userId int) error {
+	if _, err := authorizeTask(s.db, taskId, userId, accessEdit); err != nil {
+		return err
+	}
+	res, err := s.db.Exec(deleteCommentById, commentId, taskId, userId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// GetVersions lists the former bodies of a comment, oldest first.
+func (s *CommentService) GetVersions(taskId, commentId, userId int) ([]models.CommentVersion, error) {
+	if _, err := authorizeTask(s.db, taskId, userId, accessView); err != nil {
+		return nil, err
+	}
+	var ok bool
+	if err := s.db.QueryRow(commentExists, commentId, taskId).Scan(&ok); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	versions := []models.CommentVersion{}
+	rows, err := s.db.Query(getVersions, commentId, taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version models.CommentVersion
+		if err := rows.Scan(&version.Body, &version.CreateAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"trackerApp/internal/models"
+)
+
+// DependencyService manages "task is blocked by blocker" relations.
+type DependencyService struct {
+	db *sql.DB
+}
+
+func NewDependencyService(db *sql.DB) *DependencyService {
+	return &DependencyService{db: db}
+}
+
+// Blockers and dependents are listed as far as the user $2 may view them.
+var (
+	getBlockers = `SELECT ` + taskColumns + ` FROM tasks
+		WHERE id IN (SELECT blocker_id FROM task_dependencies WHERE task_id = $1) AND ` + viewableBy("$2") + ` AND deleted_at IS NULL ORDER BY id`
+	getDependents = `SELECT ` + taskColumns + ` FROM tasks
+		WHERE id IN (SELECT task_id FROM task_dependencies WHERE blocker_id = $1) AND ` + viewableBy("$2") + ` AND deleted_at IS NULL ORDER BY id`
+)
+
+const (
+	dependsOn = `WITH RECURSIVE upstream AS (
+			SELECT blocker_id FROM task_dependencies WHERE task_id = $1
+			UNION
+			SELECT d.blocker_id FROM task_dependencies d JOIN upstream u ON d.task_id = u.blocker_id
+		)
+		SELECT EXISTS (SELECT 1 FROM upstream WHERE blocker_id = $2)`
+	addDependency     = `INSERT INTO task_dependencies (task_id, blocker_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	removeDependency  = `DELETE FROM task_dependencies WHERE task_id = $1 AND blocker_id = $2`
+	countOpenBlockers = `SELECT COUNT(*) FROM task_dependencies d JOIN tasks b ON b.id = d.blocker_id
+		WHERE d.task_id = $1 AND b.is_complete = FALSE AND b.deleted_at IS NULL`
+	getOpenTasks        = `SELECT ` + taskColumns + ` FROM tasks WHERE ` + inTaskScope + ` AND is_complete = FALSE AND deleted_at IS NULL`
+	getOpenDependencies = `SELECT d.task_id, d.blocker_id FROM task_dependencies d JOIN tasks b ON b.id = d.blocker_id
+		WHERE d.task_id IN (SELECT id FROM tasks WHERE ` + inTaskScope + ` AND is_complete = FALSE)
+			AND b.is_complete = FALSE AND b.deleted_at IS NULL`
+)
+
+// checkBlockers refuses to complete a task that still has open blockers.
+func checkBlockers(q querier, taskId int) error {
+	var open int
+	if err := q.QueryRow(countOpenBlockers, taskId).Scan(&open); err != nil {
+		return err
+	}
+	if open > 0 {
+		return fmt.Errorf("%w: task is blocked by %d open tasks", ErrConflict, open)
+	}
+	return nil
+}
+
+func (s *DependencyService) GetBlockers(taskId, userId int) ([]models.Task, error) {
+	if _, err := authorizeTask(s.db, taskId, userId, accessView); err != nil {
+		return nil, err
+	}
+	return queryTasks(s.db, getBlockers, taskId, userId)
+}
+
+func (s *DependencyService) GetDependents(taskId, userId int) ([]models.Task, error) {
+	if _, err := authorizeTask(s.db, taskId, userId, accessView); err != nil {
+		return nil, err
+	}
+	return queryTasks(s.db, getDependents, taskId, userId)
+}
+
+func (s *DependencyService) AddBlocker(taskId, blockerId, userId int) error {
+	if taskId == blockerId {
+		return fmt.Errorf("%w: a task cannot block itself", ErrInvalidInput)
+	}
+	if _, err := authorizeTask(s.db, taskId, userId, accessEdit); err != nil {
+		return err
+	}
+	if _, err := authorizeTask(s.db, blockerId, userId, accessView); err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// serialize graph changes so that two concurrent inserts cannot close a cycle
+	if _, err := tx.Exec(`LOCK TABLE task_dependencies IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	var cycle bool
+	if err := tx.QueryRow(dependsOn, blockerId, taskId).Scan(&cycle); err != nil {
+		return err
+	}
+	if cycle {
+		return fmt.Errorf("%w: task %d already depends on task %d", ErrInvalidInput, blockerId, taskId)
+	}
+	if _, err := tx.Exec(addDependency, taskId, blockerId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *DependencyService) RemoveBlocker(taskId, blockerId, userId int) error {
+	if _, err := authorizeTask(s.db, taskId, userId, accessEdit); err != nil {
+		return err
+	}
+	res, err := s.db.Exec(removeDependency, taskId, blockerId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// GetAvailable returns the open tasks that have no open blockers, i.e. the
+// first layer of a topological order of the open dependency graph. Tasks
+// that transitively unblock more work come first, then earlier due dates.
+func (s *DependencyService) GetAvailable(userId int, workspaceId *int) ([]models.Task, error) {
+	if err := checkTaskScope(s.db, userId, workspaceId); err != nil {
+		return nil, err
+	}
+	tasks, err := queryTasks(s.db, getOpenTasks, userId, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(getOpenDependencies, userId, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blockers := make(map[int]int)
+	dependents := make(map[int][]int)
+	for rows.Next() {
+		var taskId, blockerId int
+		if err := rows.Scan(&taskId, &blockerId); err != nil {
+			return nil, err
+		}
+		blockers[taskId]++
+		dependents[blockerId] = append(dependents[blockerId], taskId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	available := []models.Task{}
+	unblocks := make(map[int]int)
+	for _, task := range tasks {
+		if blockers[task.ID] == 0 {
+			available = append(available, task)
+			unblocks[task.ID] = countReachable(task.ID, dependents)
+		}
+	}
+	sort.SliceStable(available, func(i, j int) bool {
+		a, b := available[i], available[j]
+		if unblocks[a.ID] != unblocks[b.ID] {
+			return unblocks[a.ID] > unblocks[b.ID]
+		}
+		if a.DueAt != nil && b.DueAt != nil {
+			return a.DueAt.Before(*b.DueAt)
+		}
+		return a.DueAt != nil && b.DueAt == nil
+	})
+	return available, nil
+}
+
+func countReachable(from int, edges map[int][]int) int {
+	seen := map[int]bool{from: true}
+	stack := []int{from}
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, to := range edges[next] {
+			if !seen[to] {
+				seen[to] = true
+				stack = append(stack, to)
+			}
+		}
+	}
+	return len(seen) - 1
+}
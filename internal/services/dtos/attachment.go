@@ -0,0 +1,9 @@
+package dtos
+
+import "io"
+
+type Upload struct {
+	FileName string
+	Size     int64
+	Content  io.Reader
+}
@@ -0,0 +1,12 @@
+package dtos
+
+type BoardColumnForm struct {
+	Name     string `json:"name"`
+	IsDone   bool   `json:"is_done"`
+	Position *int   `json:"position"`
+}
+
+type MoveCard struct {
+	ColumnId int  `json:"column_id"`
+	AfterId  *int `json:"after_id"`
+}
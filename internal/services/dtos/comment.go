@@ -0,0 +1,5 @@
+package dtos
+
+type CommentForm struct {
+	Body string `json:"body"`
+}
@@ -0,0 +1,6 @@
+package dtos
+
+type ActivityPage struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+}
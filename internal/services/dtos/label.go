@@ -0,0 +1,6 @@
+package dtos
+
+type LabelForm struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
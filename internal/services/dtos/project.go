@@ -0,0 +1,18 @@
+package dtos
+
+type CreateProject struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+}
+
+type UpdateProject struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	Archived    bool   `json:"archived"`
+}
+
+type MoveTask struct {
+	ProjectId *int `json:"project_id"`
+}
@@ -0,0 +1,6 @@
+package dtos
+
+type ShareForm struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
@@ -1,12 +1,68 @@
 package dtos
 
+import "time"
+
 type CreateTask struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title        string      `json:"title"`
+	Description  string      `json:"description"`
+	StartAt      *time.Time  `json:"start_at"`
+	DueAt        *time.Time  `json:"due_at"`
+	ProjectId    *int        `json:"project_id"`
+	ParentTaskId *int        `json:"parent_task_id"`
+	Recurrence   *Recurrence `json:"recurrence"`
+	StatusId     *int        `json:"status_id"`
+	Priority     string      `json:"priority"`
+	WorkspaceId  *int        `json:"-"`
 }
 
 type UpdateTask struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	IsComplete  bool   `json:"is_complete"`
+	Title          string      `json:"title"`
+	Description    string      `json:"description"`
+	IsComplete     bool        `json:"is_complete"`
+	StartAt        *time.Time  `json:"start_at"`
+	DueAt          *time.Time  `json:"due_at"`
+	OnOpenSubtasks string      `json:"on_open_subtasks"`
+	Force          bool        `json:"force"`
+	Recurrence     *Recurrence `json:"recurrence"`
+	StatusId       *int        `json:"status_id"`
+	Priority       *string     `json:"priority"`
+}
+
+type Recurrence struct {
+	Rule     string `json:"rule"`
+	TimeZone string `json:"tz"`
+}
+
+type SetParent struct {
+	ParentTaskId *int `json:"parent_task_id"`
+}
+
+type TaskFilter struct {
+	Completed    *bool      `form:"completed"`
+	Title        string     `form:"title"`
+	Description  string     `form:"desc"`
+	CreatedFrom  *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo    *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Labels       []string   `form:"label"`
+	ProjectId    *int       `form:"project_id"`
+	Inbox        bool       `form:"inbox"`
+	ParentId     *int       `form:"parent_id"`
+	Status       string     `form:"status"`
+	Priority     string     `form:"priority"`
+	AssignedToMe bool       `form:"assigned_to_me"`
+	CreatedByMe  bool       `form:"created_by_me"`
+	Unassigned   bool       `form:"unassigned"`
+	Query        string     `form:"q"`
+	Sort         string     `form:"sort"`
+	Order        string     `form:"order"`
+	Limit        int        `form:"limit"`
+	Cursor       string     `form:"cursor"`
+	WorkspaceId  *int       `form:"-"`
+}
+
+type TaskSearch struct {
+	Query       string `form:"q"`
+	Limit       int    `form:"limit"`
+	Offset      int    `form:"offset"`
+	WorkspaceId *int   `form:"-"`
 }
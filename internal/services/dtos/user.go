@@ -4,3 +4,7 @@ type UserForm struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
+
+type RefreshForm struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,16 @@
+package dtos
+
+import "trackerApp/internal/models"
+
+type ViewForm struct {
+	Name      string            `json:"name"`
+	Filter    models.ViewFilter `json:"filter"`
+	Sort      string            `json:"sort"`
+	Order     string            `json:"order"`
+	IsDefault bool              `json:"is_default"`
+}
+
+type ViewPage struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+}
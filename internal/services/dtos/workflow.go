@@ -0,0 +1,16 @@
+package dtos
+
+type Workflow struct {
+	Statuses    []WorkflowStatus     `json:"statuses"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+type WorkflowStatus struct {
+	Name       string `json:"name"`
+	IsTerminal bool   `json:"is_terminal"`
+}
+
+type WorkflowTransition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
@@ -0,0 +1,14 @@
+package dtos
+
+type WorkspaceForm struct {
+	Name string `json:"name"`
+}
+
+type InvitationForm struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type MemberRole struct {
+	Role string `json:"role"`
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+)
+
+type HistoryService struct {
+	db *sql.DB
+}
+
+func NewHistoryService(db *sql.DB) *HistoryService {
+	return &HistoryService{db: db}
+}
+
+const (
+	defaultActivityPageSize = 50
+	maxActivityPageSize     = 200
+)
+
+const (
+	taskChangeColumns = `h.id, h.task_id, u.username, h.action, h.changes, h.create_at`
+	createTaskChange  = `INSERT INTO task_history (task_id, actor_id, action, changes, create_at) VALUES ($1, $2, $3, $4, $5)`
+	getTaskHistory    = `SELECT ` + taskChangeColumns + ` FROM task_history h LEFT JOIN users u ON u.id = h.actor_id
+		WHERE h.task_id = $1 ORDER BY h.id`
+	getActivity = `SELECT ` + taskChangeColumns + ` FROM task_history h LEFT JOIN users u ON u.id = h.actor_id
+		WHERE h.actor_id = $1 AND ($2::bigint IS NULL OR h.id < $2) ORDER BY h.id DESC LIMIT $3`
+)
+
+// taskFields is what the history tracks of a task, keyed by the JSON names
+// of the fields. Times are normalized to what the database stores.
+func taskFields(task *models.Task) map[string]any {
+	if task == nil {
+		return map[string]any{}
+	}
+	var status *string
+	if task.Status != nil {
+		status = &task.Status.Name
+	}
+	return map[string]any{
+		"title":          task.Title,
+		"desc":           task.Description,
+		"is_completed":   task.IsCompleted,
+		"start_at":       storedTime(task.StartAt),
+		"due_at":         storedTime(task.DueAt),
+		"recurrence":     task.Recurrence,
+		"status":         status,
+		"priority":       task.Priority,
+		"project_id":     task.ProjectId,
+		"parent_task_id": task.ParentTaskId,
+	}
+}
+
+func storedTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	stored := t.UTC().Truncate(time.Microsecond)
+	return &stored
+}
+
+// diffTasks lists the fields that differ between two versions of a task;
+// a nil task has all fields null.
+func diffTasks(before, after *models.Task) (map[string]models.FieldChange, error) {
+	from, to := taskFields(before), taskFields(after)
+	keys := to
+	if after == nil {
+		keys = from
+	}
+	changes := make(map[string]models.FieldChange)
+	for key := range keys {
+		fromValue, err := json.Marshal(from[key])
+		if err != nil {
+			return nil, err
+		}
+		toValue, err := json.Marshal(to[key])
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(fromValue, toValue) {
+			changes[key] = models.FieldChange{From: fromValue, To: toValue}
+		}
+	}
+	return changes, nil
+}
+
+// recordTaskChange appends an entry to the history of a task. before is nil
+// for a created task, after for a task that went away; updates that change
+// no tracked field are not recorded.
+func recordTaskChange(q querier, taskId, actorId int, action string, before, after *models.Task, at time.Time) error {
+	changes, err := diffTasks(before, after)
+	if err != nil {
+		return err
+	}
+	if action == models.TaskUpdated && len(changes) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(createTaskChange, taskId, actorId, action, raw, at)
+	return err
+}
+
+func scanTaskChange(row rowScanner) (models.TaskChange, error) {
+	var change models.TaskChange
+	var changes []byte
+	if err := row.Scan(&change.ID, &change.TaskId, &change.Actor, &change.Action, &changes, &change.CreateAt); err != nil {
+		return change, err
+	}
+	err := json.Unmarshal(changes, &change.Changes)
+	return change, err
+}
+
+func queryTaskChanges(q querier, query string, args ...any) ([]models.TaskChange, error) {
+	changes := []models.TaskChange{}
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		change, err := scanTaskChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// GetTaskHistory lists the changes of a task, oldest first, to anyone who
+// can see the task.
+func (s *HistoryService) GetTaskHistory(taskId, userId int) ([]models.TaskChange, error) {
+	if _, err := authorizeTask(s.db, taskId, userId, accessView); err != nil {
+		return nil, err
+	}
+	return queryTaskChanges(s.db, getTaskHistory, taskId)
+}
+
+// GetActivity lists a page of the changes the user made, most recent first.
+func (s *HistoryService) GetActivity(userId int, page dtos.ActivityPage) (*models.ActivityPage, error) {
+	limit := page.Limit
+	if limit <= 0 {
+		limit = defaultActivityPageSize
+	}
+	limit = min(limit, maxActivityPageSize)
+	var before *int
+	if page.Cursor != "" {
+		id, err := strconv.Atoi(page.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+		}
+		before = &id
+	}
+	changes, err := queryTaskChanges(s.db, getActivity, userId, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	activity := models.ActivityPage{Changes: changes}
+	if len(changes) > limit {
+		activity.Changes = changes[:limit]
+		activity.NextCursor = strconv.Itoa(changes[limit-1].ID)
+	}
+	return &activity, nil
+}
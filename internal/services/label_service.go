@@ -0,0 +1,133 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+)
+
+type LabelService struct {
+	db *sql.DB
+}
+
+func NewLabelService(db *sql.DB) *LabelService {
+	return &LabelService{db: db}
+}
+
+const maxLabelNameLength = 64
+
+const (
+	getLabels       = `SELECT id, name, color FROM labels WHERE user_id = $1 ORDER BY name`
+	createLabel     = `INSERT INTO labels (user_id, name, color) VALUES ($1, $2, $3) RETURNING id`
+	updateLabelById = `UPDATE labels SET name=$1, color=$2 WHERE id=$3 AND user_id=$4`
+	deleteLabelById = `DELETE FROM labels WHERE id=$1 AND user_id=$2`
+	attachLabel     = `INSERT INTO task_labels (task_id, label_id)
+		SELECT $1::int, id FROM labels WHERE id = $2 AND user_id = $3
+		ON CONFLICT DO NOTHING`
+	labelExists = `SELECT EXISTS (SELECT 1 FROM labels WHERE id = $1 AND user_id = $2)`
+	detachLabel = `DELETE FROM task_labels tl USING labels l
+		WHERE tl.label_id = l.id AND tl.task_id = $1 AND tl.label_id = $2 AND l.user_id = $3`
+)
+
+func validateLabel(form dtos.LabelForm) (dtos.LabelForm, error) {
+	form.Name = strings.TrimSpace(form.Name)
+	if form.Name == "" {
+		return form, fmt.Errorf("%w: label name is required", ErrInvalidInput)
+	}
+	if len(form.Name) > maxLabelNameLength {
+		return form, fmt.Errorf("%w: label name is longer than %d characters", ErrInvalidInput, maxLabelNameLength)
+	}
+	return form, nil
+}
+
+func (s *LabelService) Get(userId int) ([]models.Label, error) {
+	labels := []models.Label{}
+	rows, err := s.db.Query(getLabels, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var label models.Label
+		if err := rows.Scan(&label.ID, &label.Name, &label.Color); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+func (s *LabelService) Create(userId int, form dtos.LabelForm) (int, error) {
+	form, err := validateLabel(form)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	if err := s.db.QueryRow(createLabel, userId, form.Name, form.Color).Scan(&id); err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%w: label %q already exists", ErrConflict, form.Name)
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *LabelService) Update(labelId, userId int, form dtos.LabelForm) error {
+	form, err := validateLabel(form)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.Exec(updateLabelById, form.Name, form.Color, labelId, userId)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: label %q already exists", ErrConflict, form.Name)
+		}
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (s *LabelService) Delete(labelId, userId int) error {
+	res, err := s.db.Exec(deleteLabelById, labelId, userId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// Attach puts one of the user's labels on a task they may edit.
+func (s *LabelService) Attach(taskId, labelId, userId int) error {
+	if _, err := authorizeTask(s.db, taskId, userId, accessEdit); err != nil {
+		return err
+	}
+	res, err := s.db.Exec(attachLabel, taskId, labelId, userId)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	// nothing inserted: either the label is already attached or it is foreign
+	var ok bool
+	if err := s.db.QueryRow(labelExists, labelId, userId).Scan(&ok); err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *LabelService) Detach(taskId, labelId, userId int) error {
+	if _, err := authorizeTask(s.db, taskId, userId, accessEdit); err != nil {
+		return err
+	}
+	res, err := s.db.Exec(detachLabel, taskId, labelId, userId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/pkg/oidc"
+)
+
+type OIDCService struct {
+	db            *sql.DB
+	auth          *AuthService
+	provider      *oidc.Provider
+	autoProvision bool
+}
+
+// NewOIDCService signs users in with provider. A nil provider turns single
+// sign-on off.
+func NewOIDCService(db *sql.DB, auth *AuthService, provider *oidc.Provider, autoProvision bool) *OIDCService {
+	return &OIDCService{db: db, auth: auth, provider: provider, autoProvision: autoProvision}
+}
+
+// oidcLoginTTL is how long a user has to sign in with the provider.
+const oidcLoginTTL = 10 * time.Minute
+
+const (
+	createOIDCLogin = `INSERT INTO oidc_logins (state_hash, nonce, code_verifier, create_at, expires_at, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	takeOIDCLogin   = `DELETE FROM oidc_logins WHERE state_hash = $1 RETURNING nonce, code_verifier, expires_at, user_id`
+	purgeOIDCLogins = `DELETE FROM oidc_logins WHERE expires_at < $1`
+	getIdentityUser = `SELECT u.id, u.username FROM user_identities i JOIN users u ON u.id = i.user_id
+		WHERE i.issuer = $1 AND i.subject = $2`
+	createIdentity = `INSERT INTO user_identities (user_id, issuer, subject, create_at) VALUES ($1, $2, $3, $4)`
+	getOIDCUser    = `SELECT id, username FROM users WHERE id = $1`
+)
+
+func (s *OIDCService) enabled() error {
+	if s.provider == nil {
+		return fmt.Errorf("%w: single sign-on is not configured", ErrNotFound)
+	}
+	return nil
+}
+
+// StartLogin begins a sign-in with the identity provider and returns the URL
+// to send the user to, together with the state of the sign-in. The caller
+// binds the state to the user's browser, which has to present it again with
+// the callback. The state, nonce and PKCE verifier of the sign-in are kept
+// until the provider sends the user back.
+func (s *OIDCService) StartLogin() (string, string, error) {
+	return s.start(nil)
+}
+
+// StartLink begins a sign-in that links the identity to the signed-in user
+// instead, which is how an existing account starts using single sign-on.
+// Only that user can finish it, with FinishLink.
+func (s *OIDCService) StartLink(userId int) (string, string, error) {
+	return s.start(&userId)
+}
+
+func (s *OIDCService) start(linkTo *int) (string, string, error) {
+	if err := s.enabled(); err != nil {
+		return "", "", err
+	}
+	state, err := newSecret()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := newSecret()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	if _, err := s.db.Exec(purgeOIDCLogins, now); err != nil {
+		return "", "", err
+	}
+	if _, err := s.db.Exec(createOIDCLogin, hashToken(state), nonce, verifier, now, now.Add(oidcLoginTTL), linkTo); err != nil {
+		return "", "", err
+	}
+	return s.provider.AuthCodeURL(state, nonce, verifier), state, nil
+}
+
+// exchange takes the sign-in of a state the provider sent the user back with
+// and trades the code for the claims of the identity. The state has to match
+// the one bound to the browser, so that nobody can slip the callback of their
+// own sign-in to someone else. Every state is valid once, whether the sign-in
+// succeeds or not.
+func (s *OIDCService) exchange(state, boundState, code string) (*oidc.Claims, *int, error) {
+	if err := s.enabled(); err != nil {
+		return nil, nil, err
+	}
+	if boundState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(boundState)) != 1 {
+		return nil, nil, fmt.Errorf("%w: the sign-in was started in another browser", ErrUnauthorized)
+	}
+	var nonce, verifier string
+	var expiresAt time.Time
+	var linkTo *int
+	err := s.db.QueryRow(takeOIDCLogin, hashToken(state)).Scan(&nonce, &verifier, &expiresAt, &linkTo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, fmt.Errorf("%w: unknown or used sign-in state", ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !time.Now().Before(expiresAt) {
+		return nil, nil, fmt.Errorf("%w: sign-in took too long", ErrUnauthorized)
+	}
+	claims, err := s.provider.Exchange(context.Background(), code, verifier, nonce)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	return claims, linkTo, nil
+}
+
+// FinishLogin completes a sign-in the provider sent the user back from with
+// a code, and starts a session like a sign-in with a password. boundState is
+// the state bound to the user's browser by StartLogin.
+func (s *OIDCService) FinishLogin(state, boundState, code string) (*models.Tokens, error) {
+	claims, linkTo, err := s.exchange(state, boundState, code)
+	if err != nil {
+		return nil, err
+	}
+	if linkTo != nil {
+		return nil, fmt.Errorf("%w: the sign-in links an identity and has to be finished while signed in", ErrUnauthorized)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	user, err := s.linkUser(tx, claims, nil, now)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.auth.startSession(tx, user, now)
+	if err != nil {
+		return nil, err
+	}
+	return tokens, tx.Commit()
+}
+
+// FinishLink completes a link started with StartLink on behalf of the same
+// signed-in user.
+func (s *OIDCService) FinishLink(userId int, state, boundState, code string) error {
+	claims, linkTo, err := s.exchange(state, boundState, code)
+	if err != nil {
+		return err
+	}
+	if linkTo == nil || *linkTo != userId {
+		return fmt.Errorf("%w: the link was started by another user", ErrForbidden)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.linkUser(tx, claims, linkTo, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// linkUser finds the user an identity of the provider belongs to. An
+// identity seen for the first time is linked to the user who started the
+// link with StartLink. Otherwise a user named after its verified email is
+// created when auto-provisioning is on; an existing account is never taken
+// over by its name alone, its user has to link the identity while signed in.
+// Created users have no usable password.
+func (s *OIDCService) linkUser(tx *sql.Tx, claims *oidc.Claims, linkTo *int, now time.Time) (models.User, error) {
+	var user models.User
+	err := tx.QueryRow(getIdentityUser, s.provider.Issuer(), claims.Subject).Scan(&user.ID, &user.Username)
+	if err == nil {
+		if linkTo != nil && *linkTo != user.ID {
+			return user, fmt.Errorf("%w: the identity is linked to another account", ErrConflict)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return user, err
+	}
+	if linkTo != nil {
+		if err := tx.QueryRow(getOIDCUser, *linkTo).Scan(&user.ID, &user.Username); err != nil {
+			return user, err
+		}
+	} else if err := s.provisionUser(tx, claims, &user); err != nil {
+		return user, err
+	}
+	if _, err := tx.Exec(createIdentity, user.ID, s.provider.Issuer(), claims.Subject, now); err != nil {
+		if isUniqueViolation(err) {
+			return user, fmt.Errorf("%w: the identity was linked meanwhile, sign in again", ErrConflict)
+		}
+		return user, err
+	}
+	return user, nil
+}
+
+func (s *OIDCService) provisionUser(tx *sql.Tx, claims *oidc.Claims, user *models.User) error {
+	if claims.Email == "" || !claims.EmailVerified {
+		return fmt.Errorf("%w: the identity provider shared no verified email", ErrForbidden)
+	}
+	err := tx.QueryRow(getUserIdByName, claims.Email).Scan(&user.ID)
+	if err == nil {
+		return fmt.Errorf("%w: an account named %s already exists, sign in to it and link the identity", ErrConflict, claims.Email)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if !s.autoProvision {
+		return fmt.Errorf("%w: there is no account for %s", ErrForbidden, claims.Email)
+	}
+	// passwords are unique; a random non-bcrypt value never matches one
+	secret, err := newSecret()
+	if err != nil {
+		return err
+	}
+	user.Username = claims.Email
+	err = tx.QueryRow(createUser, user.Username, "sso:"+secret).Scan(&user.ID)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: an account named %s already exists, sign in to it and link the identity", ErrConflict, claims.Email)
+	}
+	return err
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+)
+
+// What happens to the tasks of a deleted project.
+const (
+	ProjectDeleteInbox   = "inbox"
+	ProjectDeleteCascade = "cascade"
+)
+
+type ProjectService struct {
+	db *sql.DB
+}
+
+func NewProjectService(db *sql.DB) *ProjectService {
+	return &ProjectService{db: db}
+}
+
+const projectColumns = `id, name, description, color, archived, create_at`
+
+const (
+	getProjects       = `SELECT ` + projectColumns + ` FROM projects WHERE user_id = $1 AND (archived = FALSE OR $2) ORDER BY name, id`
+	getProjectById    = `SELECT ` + projectColumns + ` FROM projects WHERE id = $1 AND user_id = $2`
+	createProject     = `INSERT INTO projects (user_id, name, description, color) VALUES ($1, $2, $3, $4) RETURNING id`
+	updateProjectById = `UPDATE projects SET name=$1, description=$2, color=$3, archived=$4 WHERE id=$5 AND user_id=$6`
+	deleteProjectById = `DELETE FROM projects WHERE id=$1 AND user_id=$2`
+	lockProjectById   = `SELECT id FROM projects WHERE id = $1 AND user_id = $2 FOR UPDATE`
+	// the subtasks go along, as when their parent is deleted on its own
+	trashProjectTasks = `WITH RECURSIVE subtree AS (
+			SELECT id FROM tasks WHERE project_id = $1 AND user_id = $2 AND deleted_at IS NULL
+			UNION
+			SELECT t.id FROM tasks t JOIN subtree s ON t.parent_task_id = s.id WHERE t.deleted_at IS NULL
+		)
+		UPDATE tasks SET deleted_at = $3 WHERE id IN (SELECT id FROM subtree) RETURNING ` + taskColumns
+	detachProjectTasks = `UPDATE tasks SET project_id = NULL, updated_at = $2 WHERE project_id = $1 RETURNING ` + taskColumns
+	getProjectArchived = `SELECT archived FROM projects WHERE id = $1 AND user_id = $2`
+	moveTaskToProject  = `UPDATE tasks SET project_id=$1, updated_at=$2 WHERE id=$3 AND user_id=$4 AND deleted_at IS NULL`
+)
+
+func scanProject(row rowScanner) (models.Project, error) {
+	var project models.Project
+	err := row.Scan(&project.ID, &project.Name, &project.Description, &project.Color, &project.Archived, &project.CreateAt)
+	return project, err
+}
+
+func validateProjectName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return name, fmt.Errorf("%w: project name is required", ErrInvalidInput)
+	}
+	return name, nil
+}
+
+// checkProjectWritable verifies that the user may put tasks into the project
+// and returns its owner, to whom the project's tasks belong.
+func checkProjectWritable(q querier, projectId, userId int) (int, error) {
+	ownerId, err := authorizeProject(q, projectId, userId, accessEdit)
+	if errors.Is(err, ErrNotFound) {
+		return 0, fmt.Errorf("%w: project %d does not exist", ErrInvalidInput, projectId)
+	}
+	if err != nil {
+		return 0, err
+	}
+	var archived bool
+	if err := q.QueryRow(getProjectArchived, projectId, ownerId).Scan(&archived); err != nil {
+		return 0, err
+	}
+	if archived {
+		return 0, fmt.Errorf("%w: project %d is archived", ErrInvalidInput, projectId)
+	}
+	return ownerId, nil
+}
+
+func (s *ProjectService) Get(userId int, includeArchived bool) ([]models.Project, error) {
+	projects := []models.Project{}
+	rows, err := s.db.Query(getProjects, userId, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+// GetById returns a project the user owns or that is shared with them.
+func (s *ProjectService) GetById(projectId, userId int) (*models.Project, error) {
+	ownerId, err := authorizeProject(s.db, projectId, userId, accessView)
+	if err != nil {
+		return nil, err
+	}
+	project, err := scanProject(s.db.QueryRow(getProjectById, projectId, ownerId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (s *ProjectService) Create(userId int, projectDto dtos.CreateProject) (int, error) {
+	name, err := validateProjectName(projectDto.Name)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	if err := s.db.QueryRow(createProject, userId, name, projectDto.Description, projectDto.Color).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *ProjectService) Update(projectId, userId int, projectDto dtos.UpdateProject) error {
+	name, err := validateProjectName(projectDto.Name)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.Exec(updateProjectById, name, projectDto.Description, projectDto.Color, projectDto.Archived, projectId, userId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (s *ProjectService) Delete(projectId, userId int, mode string) error {
+	if mode == "" {
+		mode = ProjectDeleteInbox
+	}
+	if mode != ProjectDeleteInbox && mode != ProjectDeleteCascade {
+		return fmt.Errorf("%w: unknown delete mode %q", ErrInvalidInput, mode)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(lockProjectById, projectId, userId).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	// the tasks follow their owners' default workflows from now on; in
+	// cascade mode they go to the trash as well and can be restored from
+	// there until they are purged
+	if err := remapToDefaultWorkflows(tx, projectId, userId); err != nil {
+		return err
+	}
+	now := time.Now()
+	if mode == ProjectDeleteCascade {
+		trashed, err := queryTasks(tx, trashProjectTasks, projectId, userId, now)
+		if err != nil {
+			return err
+		}
+		for i := range trashed {
+			if err := recordTaskChange(tx, trashed[i].ID, userId, models.TaskDeleted, &trashed[i], nil, now); err != nil {
+				return err
+			}
+		}
+	}
+	// detached here rather than by the foreign key, so that it is recorded
+	detached, err := queryTasks(tx, detachProjectTasks, projectId, now)
+	if err != nil {
+		return err
+	}
+	for i := range detached {
+		before := detached[i]
+		before.ProjectId = &projectId
+		if err := recordTaskChange(tx, before.ID, userId, models.TaskUpdated, &before, &detached[i], now); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(deleteProjectById, projectId, userId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// remapToDefaultWorkflows moves the tasks of a project into the default
+// workflow of their owners, who are not necessarily the project's owner.
+func remapToDefaultWorkflows(tx *sql.Tx, projectId, actorId int) error {
+	rows, err := tx.Query(getProjectTaskOwners, projectId)
+	if err != nil {
+		return err
+	}
+	var owners []int
+	for rows.Next() {
+		var ownerId int
+		if err := rows.Scan(&ownerId); err != nil {
+			rows.Close()
+			return err
+		}
+		owners = append(owners, ownerId)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, ownerId := range owners {
+		workflow, err := resolveWorkflow(tx, ownerId, nil)
+		if err != nil {
+			return err
+		}
+		if err := remapTasks(tx, workflow, actorId, getProjectOwnerTasks, projectId, ownerId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveTask puts a task into one of its owner's projects, or takes it out of
+// its project when projectId is nil.
+func (s *ProjectService) MoveTask(taskId, userId int, projectId *int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ownerId, err := authorizeTask(tx, taskId, userId, accessEdit)
+	if err != nil {
+		return err
+	}
+	current, err := scanTask(tx.QueryRow(getTaskForUpdate, taskId, ownerId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if projectId != nil {
+		if current.WorkspaceId != nil {
+			return fmt.Errorf("%w: workspace tasks cannot be put into projects", ErrInvalidInput)
+		}
+		projectOwnerId, err := checkProjectWritable(tx, *projectId, userId)
+		if err != nil {
+			return err
+		}
+		if projectOwnerId != ownerId {
+			return fmt.Errorf("%w: project %d belongs to another user", ErrInvalidInput, *projectId)
+		}
+	}
+	now := time.Now()
+	if _, err := tx.Exec(moveTaskToProject, projectId, now, taskId, ownerId); err != nil {
+		return err
+	}
+	moved := current
+	moved.ProjectId = projectId
+	if err := recordTaskChange(tx, taskId, userId, models.TaskUpdated, &current, &moved, now); err != nil {
+		return err
+	}
+	// the task keeps a status of the same name, if the target workflow has one
+	workflow, err := resolveWorkflow(tx, ownerId, projectId)
+	if err != nil {
+		return err
+	}
+	if err := remapTasks(tx, workflow, userId, getWorkflowTask, taskId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -2,34 +2,220 @@ package services
 
 import (
 	"database/sql"
+	"io"
+	"time"
 	"trackerApp/internal/models"
 	"trackerApp/internal/services/dtos"
+	"trackerApp/pkg/oidc"
+	"trackerApp/pkg/signing"
+	"trackerApp/pkg/storage"
 )
 
 // будет использоваться pgx драйвер
 
 type ITaskService interface {
-	Get(userId int) ([]models.Task, error)
+	Get(userId int, filter dtos.TaskFilter) (*models.TaskPage, error)
+	Search(userId int, search dtos.TaskSearch) (*models.SearchPage, error)
 	GetById(taskId, userId int) (*models.Task, error)
+	GetOverdue(userId int, workspaceId *int, now time.Time) ([]models.Task, error)
+	GetDueBetween(userId int, workspaceId *int, from, to time.Time) ([]models.Task, error)
 	Create(userId int, taskDto dtos.CreateTask) (int, error)
 	Update(taskId, userId int, updateTask dtos.UpdateTask) error
 	Delete(taskId, userId int) error
+	GetTrash(userId int, workspaceId *int) ([]models.TrashedTask, error)
+	Restore(taskId, userId int) error
+	Purge(taskId, userId int) error
+	PurgeTrash(before time.Time) (int, error)
+	SetParent(taskId, userId int, parentId *int) error
+	GetSubtree(taskId, userId int) (*models.TaskNode, error)
+	PreviewRecurrence(recurrence dtos.Recurrence, start time.Time, n int) ([]time.Time, error)
+}
+
+type ILabelService interface {
+	Get(userId int) ([]models.Label, error)
+	Create(userId int, form dtos.LabelForm) (int, error)
+	Update(labelId, userId int, form dtos.LabelForm) error
+	Delete(labelId, userId int) error
+	Attach(taskId, labelId, userId int) error
+	Detach(taskId, labelId, userId int) error
+}
+
+type IProjectService interface {
+	Get(userId int, includeArchived bool) ([]models.Project, error)
+	GetById(projectId, userId int) (*models.Project, error)
+	Create(userId int, projectDto dtos.CreateProject) (int, error)
+	Update(projectId, userId int, projectDto dtos.UpdateProject) error
+	Delete(projectId, userId int, mode string) error
+	MoveTask(taskId, userId int, projectId *int) error
+}
+
+type IDependencyService interface {
+	GetBlockers(taskId, userId int) ([]models.Task, error)
+	GetDependents(taskId, userId int) ([]models.Task, error)
+	AddBlocker(taskId, blockerId, userId int) error
+	RemoveBlocker(taskId, blockerId, userId int) error
+	GetAvailable(userId int, workspaceId *int) ([]models.Task, error)
+}
+
+type IWorkflowService interface {
+	Get(userId int, projectId *int) (*models.Workflow, error)
+	Replace(userId int, projectId *int, workflowDto dtos.Workflow) error
+}
+
+type IBoardService interface {
+	GetBoard(userId int, workspaceId, projectId *int) (*models.Board, error)
+	GetColumns(userId int) ([]models.BoardColumn, error)
+	CreateColumn(userId int, form dtos.BoardColumnForm) (int, error)
+	UpdateColumn(columnId, userId int, form dtos.BoardColumnForm) error
+	DeleteColumn(columnId, userId int) error
+	MoveCard(taskId, userId int, move dtos.MoveCard) error
+}
+
+type ICommentService interface {
+	Get(taskId, userId int) ([]models.Comment, error)
+	Create(taskId, userId int, form dtos.CommentForm) (int, error)
+	Update(taskId, commentId, userId int, form dtos.CommentForm) error
+	Delete(taskId, commentId, userId int) error
+	GetVersions(taskId, commentId, userId int) ([]models.CommentVersion, error)
+}
+
+type IAttachmentService interface {
+	Get(taskId, userId int) ([]models.Attachment, error)
+	Create(taskId, userId int, upload dtos.Upload) (int, error)
+	Open(taskId, attachmentId, userId int) (*models.Attachment, io.ReadCloser, error)
+	Delete(taskId, attachmentId, userId int) error
+	MaxSize() int64
+}
+
+type IShareService interface {
+	GetTaskShares(taskId, userId int) ([]models.Share, error)
+	ShareTask(taskId, userId int, form dtos.ShareForm) error
+	RevokeTask(taskId, shareUserId, userId int) error
+	GetProjectShares(projectId, userId int) ([]models.Share, error)
+	ShareProject(projectId, userId int, form dtos.ShareForm) error
+	RevokeProject(projectId, shareUserId, userId int) error
+	GetSharedWithMe(userId int) ([]models.SharedTask, error)
+}
+
+type IViewService interface {
+	Get(userId int) ([]models.SavedView, error)
+	GetById(viewId, userId int) (*models.SavedView, error)
+	Create(userId int, form dtos.ViewForm) (int, error)
+	Update(viewId, userId int, form dtos.ViewForm) error
+	Delete(viewId, userId int) error
+	GetTasks(viewId, userId int, workspaceId *int, page dtos.ViewPage) (*models.TaskPage, error)
+}
+
+type IAssigneeService interface {
+	Get(taskId, userId int) ([]models.Assignee, error)
+	Assign(taskId, assigneeId, userId int) error
+	Unassign(taskId, assigneeId, userId int) error
+	GetChanges(taskId, userId int) ([]models.AssignmentChange, error)
+}
+
+type IWorkspaceService interface {
+	Role(workspaceId, userId int) (string, error)
+	Get(userId int) ([]models.Workspace, error)
+	Create(userId int, form dtos.WorkspaceForm) (int, error)
+	Update(workspaceId, userId int, form dtos.WorkspaceForm) error
+	Delete(workspaceId, userId int) error
+	GetMembers(workspaceId, userId int) ([]models.Member, error)
+	SetMemberRole(workspaceId, memberId, userId int, role string) error
+	RemoveMember(workspaceId, memberId, userId int) error
+	Invite(workspaceId, userId int, form dtos.InvitationForm) (int, error)
+	GetInvitations(userId int) ([]models.Invitation, error)
+	AcceptInvitation(invitationId, userId int) error
+	DeclineInvitation(invitationId, userId int) error
+}
+
+type IHistoryService interface {
+	GetTaskHistory(taskId, userId int) ([]models.TaskChange, error)
+	GetActivity(userId int, page dtos.ActivityPage) (*models.ActivityPage, error)
+}
+
+type IApiTokenService interface {
+	Get(userId int) ([]models.ApiToken, error)
+	Create(userId int, form dtos.ApiTokenForm) (*models.NewApiToken, error)
+	Revoke(tokenId, userId int) error
+	ParseApiToken(token string) (*models.Principal, error)
 }
 
 type IAuthService interface {
 	AddUser(form dtos.UserForm) (int, error)
-	GenerateJwt(form dtos.UserForm) (string, error)
-	ParseJwt(accessToken string) (int, error)
+	GenerateJwt(form dtos.UserForm) (*models.Tokens, error)
+	Refresh(refreshToken string) (*models.Tokens, error)
+	Logout(principal models.Principal) error
+	ParseJwt(accessToken string) (*models.Principal, error)
+	JWKS() signing.JWKS
+}
+
+type IOIDCService interface {
+	StartLogin() (string, string, error)
+	StartLink(userId int) (string, string, error)
+	FinishLogin(state, boundState, code string) (*models.Tokens, error)
+	FinishLink(userId int, state, boundState, code string) error
 }
 
 type Service struct {
 	ITaskService
+	ILabelService
+	IProjectService
+	IDependencyService
+	IWorkflowService
+	IBoardService
+	ICommentService
+	IAttachmentService
+	IShareService
+	IWorkspaceService
+	IAssigneeService
+	IViewService
+	IHistoryService
+	IApiTokenService
 	IAuthService
+	IOIDCService
+}
+
+// Config holds what the services need besides the database.
+type Config struct {
+	// BlobStore keeps the contents of attachments.
+	BlobStore storage.Store
+	// MaxAttachmentSize is the upload limit in bytes, DefaultMaxAttachmentSize if zero.
+	MaxAttachmentSize int64
+	// AttachmentTypes lists the accepted media types, "image/*" style
+	// wildcards included. DefaultAttachmentTypes if empty.
+	AttachmentTypes []string
+	// SigningKeys sign the access tokens and verify them. Required.
+	SigningKeys *signing.KeySet
+	// AccessTokenTTL and RefreshTokenTTL are the token lifetimes,
+	// DefaultAccessTokenTTL and DefaultRefreshTokenTTL if zero.
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// OIDC is the identity provider users sign in with; nil turns single
+	// sign-on off. OIDCAutoProvision creates the users it knows and the
+	// tracker does not.
+	OIDC              *oidc.Provider
+	OIDCAutoProvision bool
 }
 
-func NewService(db *sql.DB) *Service {
+func NewService(db *sql.DB, cfg Config) *Service {
+	tasks := NewTaskService(db, cfg.BlobStore)
+	auth := NewAuthService(db, cfg.SigningKeys, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
 	return &Service{
-		ITaskService: NewTaskService(db),
-		IAuthService: NewAuthService(db),
+		ITaskService:       tasks,
+		ILabelService:      NewLabelService(db),
+		IProjectService:    NewProjectService(db),
+		IDependencyService: NewDependencyService(db),
+		IWorkflowService:   NewWorkflowService(db),
+		IBoardService:      NewBoardService(db),
+		ICommentService:    NewCommentService(db),
+		IAttachmentService: NewAttachmentService(db, cfg.BlobStore, cfg.MaxAttachmentSize, cfg.AttachmentTypes),
+		IShareService:      NewShareService(db),
+		IWorkspaceService:  NewWorkspaceService(db, cfg.BlobStore),
+		IAssigneeService:   NewAssigneeService(db),
+		IViewService:       NewViewService(db, tasks),
+		IHistoryService:    NewHistoryService(db),
+		IApiTokenService:   NewApiTokenService(db),
+		IAuthService:       auth,
+		IOIDCService:       NewOIDCService(db, auth, cfg.OIDC, cfg.OIDCAutoProvision),
 	}
 }
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+)
+
+type ShareService struct {
+	db *sql.DB
+}
+
+func NewShareService(db *sql.DB) *ShareService {
+	return &ShareService{db: db}
+}
+
+// accessLevel is what a user may do with a task or project, weakest first.
+type accessLevel int
+
+const (
+	accessNone accessLevel = iota
+	accessView
+	accessEdit
+	accessOwner
+)
+
+func (l accessLevel) String() string {
+	switch l {
+	case accessView:
+		return "view"
+	case accessEdit:
+		return "edit"
+	case accessOwner:
+		return "owner"
+	}
+	return "no"
+}
+
+const (
+	// personal tasks belong to their creator, workspace tasks to the members;
+	// shares add to either
+	taskAccess = `SELECT t.user_id, GREATEST(
+			CASE WHEN t.workspace_id IS NULL AND t.user_id = $2 THEN 3 ELSE 0 END,
+			COALESCE((SELECT CASE
+					WHEN m.role IN ('owner', 'admin') THEN 3
+					WHEN m.role = 'member' AND t.user_id = $2 THEN 3
+					WHEN m.role = 'member' THEN 2
+					ELSE 1 END
+				FROM workspace_members m WHERE m.workspace_id = t.workspace_id AND m.user_id = $2), 0),
+			CASE
+				WHEN EXISTS (SELECT 1 FROM task_shares s WHERE s.task_id = t.id AND s.user_id = $2 AND s.role = 'editor')
+					OR EXISTS (SELECT 1 FROM project_shares s WHERE s.project_id = t.project_id AND s.user_id = $2 AND s.role = 'editor') THEN 2
+				WHEN EXISTS (SELECT 1 FROM task_shares s WHERE s.task_id = t.id AND s.user_id = $2)
+					OR EXISTS (SELECT 1 FROM project_shares s WHERE s.project_id = t.project_id AND s.user_id = $2) THEN 1
+				ELSE 0 END)
+		FROM tasks t WHERE t.id = $1`
+	getTaskAccess        = taskAccess + ` AND t.deleted_at IS NULL`
+	getTrashedTaskAccess = taskAccess + ` AND t.deleted_at IS NOT NULL`
+	getProjectAccess     = `SELECT p.user_id, CASE
+			WHEN p.user_id = $2 THEN 3
+			ELSE COALESCE((SELECT CASE s.role WHEN 'editor' THEN 2 ELSE 1 END FROM project_shares s
+				WHERE s.project_id = p.id AND s.user_id = $2), 0) END
+		FROM projects p WHERE p.id = $1`
+	getUserIdByName = `SELECT id FROM users WHERE username = $1`
+	getTaskShares   = `SELECT s.user_id, u.username, s.role, s.create_at FROM task_shares s
+		JOIN users u ON u.id = s.user_id WHERE s.task_id = $1 ORDER BY u.username`
+	upsertTaskShare = `INSERT INTO task_shares (task_id, user_id, role, create_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (task_id, user_id) DO UPDATE SET role = EXCLUDED.role`
+	deleteTaskShare  = `DELETE FROM task_shares WHERE task_id = $1 AND user_id = $2`
+	getProjectShares = `SELECT s.user_id, u.username, s.role, s.create_at FROM project_shares s
+		JOIN users u ON u.id = s.user_id WHERE s.project_id = $1 ORDER BY u.username`
+	upsertProjectShare = `INSERT INTO project_shares (project_id, user_id, role, create_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id, user_id) DO UPDATE SET role = EXCLUDED.role`
+	deleteProjectShare = `DELETE FROM project_shares WHERE project_id = $1 AND user_id = $2`
+	getSharedTasks     = `WITH shared AS (
+			SELECT task_id, CASE WHEN bool_or(role = 'editor') THEN 'editor' ELSE 'viewer' END AS role FROM (
+				SELECT task_id, role FROM task_shares WHERE user_id = $1
+				UNION ALL
+				SELECT t.id, s.role FROM project_shares s JOIN tasks t ON t.project_id = s.project_id WHERE s.user_id = $1
+			) grants GROUP BY task_id
+		)
+		SELECT ` + taskColumns + `, (SELECT u.username FROM users u WHERE u.id = tasks.user_id), shared.role
+		FROM tasks JOIN shared ON shared.task_id = tasks.id WHERE tasks.user_id <> $1 AND tasks.deleted_at IS NULL ORDER BY tasks.id`
+)
+
+// authorizeTask checks that a user has at least the given access to a task
+// and returns the task's owner, whose workflow and board govern the task.
+// Tasks the user cannot see at all are reported as not found.
+func authorizeTask(q querier, taskId, userId int, level accessLevel) (int, error) {
+	return authorize(q, getTaskAccess, "task", taskId, userId, level)
+}
+
+// viewableBy matches the tasks the user bound to the placeholder may view,
+// the ones taskAccess grants any access to.
+func viewableBy(user string) string {
+	return `((tasks.workspace_id IS NULL AND tasks.user_id = ` + user + `)
+		OR tasks.workspace_id IN (SELECT m.workspace_id FROM workspace_members m WHERE m.user_id = ` + user + `)
+		OR tasks.id IN (SELECT s.task_id FROM task_shares s WHERE s.user_id = ` + user + `)
+		OR tasks.project_id IN (SELECT s.project_id FROM project_shares s WHERE s.user_id = ` + user + `))`
+}
+
+// authorizeTrashedTask is authorizeTask for tasks in the trash.
+func authorizeTrashedTask(q querier, taskId, userId int, level accessLevel) (int, error) {
+	return authorize(q, getTrashedTaskAccess, "task", taskId, userId, level)
+}
+
+// authorizeProject is authorizeTask for projects.
+func authorizeProject(q querier, projectId, userId int, level accessLevel) (int, error) {
+	return authorize(q, getProjectAccess, "project", projectId, userId, level)
+}
+
+func authorize(q querier, query, kind string, id, userId int, level accessLevel) (int, error) {
+	var ownerId int
+	var granted accessLevel
+	err := q.QueryRow(query, id, userId).Scan(&ownerId, &granted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if granted == accessNone {
+		return 0, ErrNotFound
+	}
+	if granted < level {
+		return 0, fmt.Errorf("%w: %s access to %s %d is required", ErrForbidden, level, kind, id)
+	}
+	return ownerId, nil
+}
+
+// extraColumns lets scanTask read rows that carry more columns after the
+// task's own.
+type extraColumns struct {
+	row  rowScanner
+	dest []any
+}
+
+func (e extraColumns) Scan(dest ...any) error {
+	return e.row.Scan(append(dest, e.dest...)...)
+}
+
+// shareTarget resolves the user a share is granted to.
+func (s *ShareService) shareTarget(form dtos.ShareForm, userId int) (int, error) {
+	if form.Role != models.ShareViewer && form.Role != models.ShareEditor {
+		return 0, fmt.Errorf("%w: role must be %q or %q", ErrInvalidInput, models.ShareViewer, models.ShareEditor)
+	}
+	var targetId int
+	err := s.db.QueryRow(getUserIdByName, form.Username).Scan(&targetId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: user %q does not exist", ErrInvalidInput, form.Username)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if targetId == userId {
+		return 0, fmt.Errorf("%w: cannot share with yourself", ErrInvalidInput)
+	}
+	return targetId, nil
+}
+
+func queryShares(q querier, query string, id int) ([]models.Share, error) {
+	shares := []models.Share{}
+	rows, err := q.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var share models.Share
+		if err := rows.Scan(&share.UserId, &share.Username, &share.Role, &share.CreateAt); err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+func (s *ShareService) GetTaskShares(taskId, userId int) ([]models.Share, error) {
+	if _, err := authorizeTask(s.db, taskId, userId, accessOwner); err != nil {
+		return nil, err
+	}
+	return queryShares(s.db, getTaskShares, taskId)
+}
+
+// ShareTask grants a user access to a task, or changes the role of an
+// existing share.
+func (s *ShareService) ShareTask(taskId, userId int, form dtos.ShareForm) error {
+	targetId, err := s.shareTarget(form, userId)
+	if err != nil {
+		return err
+	}
+	if _, err := authorizeTask(s.db, taskId, userId, accessOwner); err != nil {
+		return err
+	}
+	_, err = s.db.Exec(upsertTaskShare, taskId, targetId, form.Role, time.Now())
+	return err
+}
+
+// RevokeTask removes a share. Owners revoke anyone's access, other users
+// only their own.
+func (s *ShareService) RevokeTask(taskId, shareUserId, userId int) error {
+	level := accessOwner
+	if shareUserId == userId {
+		level = accessView
+	}
+	if _, err := authorizeTask(s.db, taskId, userId, level); err != nil {
+		return err
+	}
+	res, err := s.db.Exec(deleteTaskShare, taskId, shareUserId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (s *ShareService) GetProjectShares(projectId, userId int) ([]models.Share, error) {
+	if _, err := authorizeProject(s.db, projectId, userId, accessOwner); err != nil {
+		return nil, err
+	}
+	return queryShares(s.db, getProjectShares, projectId)
+}
+
+// ShareProject grants a user access to every task of a project, or changes
+// the role of an existing share.
+func (s *ShareService) ShareProject(projectId, userId int, form dtos.ShareForm) error {
+	targetId, err := s.shareTarget(form, userId)
+	if err != nil {
+		return err
+	}
+	if _, err := authorizeProject(s.db, projectId, userId, accessOwner); err != nil {
+		return err
+	}
+	_, err = s.db.Exec(upsertProjectShare, projectId, targetId, form.Role, time.Now())
+	return err
+}
+
+// RevokeProject removes a project share. Owners revoke anyone's access,
+// other users only their own.
+func (s *ShareService) RevokeProject(projectId, shareUserId, userId int) error {
+	level := accessOwner
+	if shareUserId == userId {
+		level = accessView
+	}
+	if _, err := authorizeProject(s.db, projectId, userId, level); err != nil {
+		return err
+	}
+	res, err := s.db.Exec(deleteProjectShare, projectId, shareUserId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// GetSharedWithMe lists the tasks other users shared with the user.
+func (s *ShareService) GetSharedWithMe(userId int) ([]models.SharedTask, error) {
+	rows, err := s.db.Query(getSharedTasks, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	var owners, roles []string
+	for rows.Next() {
+		var owner, role string
+		task, err := scanTask(extraColumns{row: rows, dest: []any{&owner, &role}})
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+		owners, roles = append(owners, owner), append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadTaskDetails(s.db, tasks); err != nil {
+		return nil, err
+	}
+	shared := make([]models.SharedTask, len(tasks))
+	for i, task := range tasks {
+		shared[i] = models.SharedTask{Task: task, Owner: owners[i], Role: roles[i]}
+	}
+	return shared, nil
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+)
+
+const (
+	defaultTaskPageSize = 50
+	maxTaskPageSize     = 200
+)
+
+type taskSortKey struct {
+	column string
+	cast   string
+	value  func(task models.Task) string
+}
+
+var taskSortKeys = map[string]taskSortKey{
+	"created":    {"create_at", "timestamptz", func(t models.Task) string { return t.CreateAt.Format(time.RFC3339Nano) }},
+	"updated":    {"updated_at", "timestamptz", func(t models.Task) string { return t.UpdatedAt.Format(time.RFC3339Nano) }},
+	"title":      {"title", "text", func(t models.Task) string { return t.Title }},
+	"completion": {"is_complete", "boolean", func(t models.Task) string { return strconv.FormatBool(t.IsCompleted) }},
+	"priority":   {"priority", "smallint", func(t models.Task) string { return strconv.Itoa(int(t.Priority)) }},
+}
+
+// taskCursor points just past the last task of a page. It is handed to clients
+// as an opaque base64 token.
+type taskCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func encodeTaskCursor(cursor taskCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeTaskCursor(token string) (taskCursor, error) {
+	var cursor taskCursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return cursor, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	return cursor, nil
+}
+
+// whereTaskScope limits a task query to the tasks of a workspace, or to the
+// user's personal tasks when no workspace is given.
+func whereTaskScope(b *queryBuilder, userId int, workspaceId *int) {
+	if workspaceId != nil {
+		b.where("workspace_id = " + b.arg(*workspaceId))
+	} else {
+		b.where("user_id = " + b.arg(userId))
+		b.where("workspace_id IS NULL")
+	}
+}
+
+func applyTaskFilter(b *queryBuilder, userId int, filter dtos.TaskFilter) error {
+	if filter.AssignedToMe && filter.Unassigned {
+		return fmt.Errorf("%w: assigned_to_me and unassigned exclude each other", ErrInvalidInput)
+	}
+	if filter.Completed != nil {
+		b.where("is_complete = " + b.arg(*filter.Completed))
+	}
+	if filter.Title != "" {
+		b.where("title ILIKE " + b.arg(containsPattern(filter.Title)))
+	}
+	if filter.Description != "" {
+		b.where("description ILIKE " + b.arg(containsPattern(filter.Description)))
+	}
+	if filter.CreatedFrom != nil {
+		b.where("create_at >= " + b.arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		b.where("create_at < " + b.arg(*filter.CreatedTo))
+	}
+	if filter.ProjectId != nil {
+		b.where("project_id = " + b.arg(*filter.ProjectId))
+	}
+	if filter.Inbox {
+		b.where("project_id IS NULL")
+	}
+	if filter.ParentId != nil {
+		b.where("parent_task_id = " + b.arg(*filter.ParentId))
+	}
+	for _, label := range filter.Labels {
+		b.where(`EXISTS (SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id
+			WHERE tl.task_id = tasks.id AND l.name = ` + b.arg(label) + `)`)
+	}
+	if filter.Status != "" {
+		b.where("status_id IN (SELECT id FROM workflow_statuses WHERE name = " + b.arg(filter.Status) + ")")
+	}
+	if filter.Priority != "" {
+		priority, err := parsePriority(filter.Priority)
+		if err != nil {
+			return err
+		}
+		b.where("priority = " + b.arg(int16(priority)))
+	}
+	if filter.AssignedToMe {
+		b.where("EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.task_id = tasks.id AND ta.user_id = " + b.arg(userId) + ")")
+	}
+	if filter.Unassigned {
+		b.where("NOT EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.task_id = tasks.id)")
+	}
+	if filter.CreatedByMe {
+		b.where("user_id = " + b.arg(userId))
+	}
+	if strings.TrimSpace(filter.Query) != "" {
+		cond, err := compileTaskQuery(b, filter.Query, userId, time.Now())
+		if err != nil {
+			return err
+		}
+		b.where(cond)
+	}
+	return nil
+}
+
+func (s *TaskService) Get(userId int, filter dtos.TaskFilter) (*models.TaskPage, error) {
+	var viewId *int
+	if !hasCriteria(filter) {
+		view, err := scanView(s.db.QueryRow(getDefaultView, userId))
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		if view != nil {
+			filter, viewId = viewTaskFilter(view, filter), &view.ID
+		}
+	}
+	if filter.Sort == "" {
+		filter.Sort = "created"
+	}
+	if filter.Order == "" {
+		filter.Order = "asc"
+	}
+	sortKey, ok := taskSortKeys[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort key %q", ErrInvalidInput, filter.Sort)
+	}
+	if filter.Order != "asc" && filter.Order != "desc" {
+		return nil, fmt.Errorf("%w: order must be asc or desc", ErrInvalidInput)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultTaskPageSize
+	}
+	limit = min(limit, maxTaskPageSize)
+
+	if err := checkTaskScope(s.db, userId, filter.WorkspaceId); err != nil {
+		return nil, err
+	}
+	var b queryBuilder
+	if (filter.AssignedToMe || filter.ProjectId != nil) && filter.WorkspaceId == nil {
+		// tasks are assigned in workspaces and through shares, not only on
+		// personal tasks, and the tasks of a shared project are its owner's
+		b.where(viewableBy(b.arg(userId)))
+	} else {
+		whereTaskScope(&b, userId, filter.WorkspaceId)
+	}
+	b.where("deleted_at IS NULL")
+	if err := applyTaskFilter(&b, userId, filter); err != nil {
+		return nil, err
+	}
+
+	page := models.TaskPage{Tasks: []models.Task{}, ViewId: viewId}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM tasks`+b.whereClause(), b.args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeTaskCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != filter.Sort || cursor.Order != filter.Order {
+			return nil, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidInput)
+		}
+		op := ">"
+		if filter.Order == "desc" {
+			op = "<"
+		}
+		b.where(fmt.Sprintf("(%s, id) %s (%s::%s, %s)", sortKey.column, op, b.arg(cursor.Value), sortKey.cast, b.arg(cursor.ID)))
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM tasks%s ORDER BY %s %s, id %s LIMIT %s`,
+		taskColumns, b.whereClause(), sortKey.column, filter.Order, filter.Order, b.arg(limit+1))
+	tasks, err := queryTasks(s.db, query, b.args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+		last := tasks[limit-1]
+		page.NextCursor = encodeTaskCursor(taskCursor{
+			Sort:  filter.Sort,
+			Order: filter.Order,
+			Value: sortKey.value(last),
+			ID:    last.ID,
+		})
+	}
+	if tasks != nil {
+		page.Tasks = tasks
+	}
+	return &page, nil
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/pkg/taskquery"
+)
+
+// taskQueryCompiler turns a taskquery tree into a condition on the tasks
+// table. Every value becomes a query argument; only column names and
+// operators chosen here end up in the SQL text.
+type taskQueryCompiler struct {
+	b      *queryBuilder
+	userId int
+	now    time.Time
+}
+
+type termCompiler func(c *taskQueryCompiler, term *taskquery.Term) (string, error)
+
+var taskQueryKeys = map[string]termCompiler{
+	"is":        (*taskQueryCompiler).is,
+	"has":       (*taskQueryCompiler).has,
+	"title":     textTerm("title"),
+	"desc":      textTerm("description"),
+	"created":   timeTerm("create_at"),
+	"updated":   timeTerm("updated_at"),
+	"completed": timeTerm("completed_at"),
+	"start":     timeTerm("start_at"),
+	"due":       timeTerm("due_at"),
+	"priority":  (*taskQueryCompiler).priority,
+	"status":    (*taskQueryCompiler).status,
+	"label":     (*taskQueryCompiler).label,
+	"project":   (*taskQueryCompiler).project,
+	"assignee":  (*taskQueryCompiler).assignee,
+	"creator":   (*taskQueryCompiler).creator,
+}
+
+// compileTaskQuery parses a query such as `is:open due:<2024-11-01` and
+// returns the equivalent condition on the tasks table.
+func compileTaskQuery(b *queryBuilder, query string, userId int, now time.Time) (string, error) {
+	node, err := taskquery.Parse(query)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	c := &taskQueryCompiler{b: b, userId: userId, now: now}
+	cond, err := c.compile(node)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return cond, nil
+}
+
+func (c *taskQueryCompiler) compile(node taskquery.Node) (string, error) {
+	switch node := node.(type) {
+	case *taskquery.And:
+		return c.join(node.Nodes, " AND ")
+	case *taskquery.Or:
+		return c.join(node.Nodes, " OR ")
+	case *taskquery.Not:
+		cond, err := c.compile(node.Node)
+		if err != nil {
+			return "", err
+		}
+		// conditions on nullable columns may be NULL, which NOT keeps NULL
+		return "NOT COALESCE(" + cond + ", FALSE)", nil
+	case *taskquery.Text:
+		pattern := c.b.arg(containsPattern(node.Value))
+		return "(title ILIKE " + pattern + " OR description ILIKE " + pattern + ")", nil
+	case *taskquery.Term:
+		compile, ok := taskQueryKeys[node.Key]
+		if !ok {
+			return "", &taskquery.Error{Pos: node.At, Msg: fmt.Sprintf("unknown key %q", node.Key)}
+		}
+		return compile(c, node)
+	}
+	return "", fmt.Errorf("unexpected node %T", node)
+}
+
+func (c *taskQueryCompiler) join(nodes []taskquery.Node, op string) (string, error) {
+	conds := make([]string, len(nodes))
+	for i, node := range nodes {
+		cond, err := c.compile(node)
+		if err != nil {
+			return "", err
+		}
+		conds[i] = cond
+	}
+	return "(" + strings.Join(conds, op) + ")", nil
+}
+
+func valueError(term *taskquery.Term, format string, args ...any) error {
+	return &taskquery.Error{Pos: term.ValueAt, Msg: fmt.Sprintf(format, args...)}
+}
+
+// equalsOnly rejects comparison operators on keys that have no order.
+func equalsOnly(term *taskquery.Term) error {
+	if term.Op != taskquery.Eq {
+		return &taskquery.Error{Pos: term.At, Msg: fmt.Sprintf("%q does not support %s", term.Key, term.Op)}
+	}
+	return nil
+}
+
+func (c *taskQueryCompiler) is(term *taskquery.Term) (string, error) {
+	if err := equalsOnly(term); err != nil {
+		return "", err
+	}
+	switch strings.ToLower(term.Value) {
+	case "open":
+		return "is_complete = FALSE", nil
+	case "done", "completed", "closed":
+		return "is_complete = TRUE", nil
+	case "overdue":
+		return "(is_complete = FALSE AND due_at IS NOT NULL AND due_at < " + c.b.arg(c.now) + ")", nil
+	case "recurring":
+		return "recurrence IS NOT NULL", nil
+	case "blocked":
+		return `EXISTS (SELECT 1 FROM task_dependencies d JOIN tasks blocker ON blocker.id = d.blocker_id
+			WHERE d.task_id = tasks.id AND blocker.is_complete = FALSE AND blocker.deleted_at IS NULL)`, nil
+	}
+	return "", valueError(term, "is: expects open, done, overdue, recurring or blocked")
+}
+
+func (c *taskQueryCompiler) has(term *taskquery.Term) (string, error) {
+	if err := equalsOnly(term); err != nil {
+		return "", err
+	}
+	switch strings.ToLower(term.Value) {
+	case "due":
+		return "due_at IS NOT NULL", nil
+	case "start":
+		return "start_at IS NOT NULL", nil
+	case "project":
+		return "project_id IS NOT NULL", nil
+	case "parent":
+		return "parent_task_id IS NOT NULL", nil
+	case "labels":
+		return "EXISTS (SELECT 1 FROM task_labels tl WHERE tl.task_id = tasks.id)", nil
+	case "assignees":
+		return "EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.task_id = tasks.id)", nil
+	case "comments":
+		return "EXISTS (SELECT 1 FROM comments cm WHERE cm.task_id = tasks.id)", nil
+	}
+	return "", valueError(term, "has: expects due, start, project, parent, labels, assignees or comments")
+}
+
+func textTerm(column string) termCompiler {
+	return func(c *taskQueryCompiler, term *taskquery.Term) (string, error) {
+		if err := equalsOnly(term); err != nil {
+			return "", err
+		}
+		if term.Value == "" {
+			return "", valueError(term, "%s: expects a non-empty text", term.Key)
+		}
+		return column + " ILIKE " + c.b.arg(containsPattern(term.Value)), nil
+	}
+}
+
+// timeTerm compares a timestamp column with a date (YYYY-MM-DD, a whole UTC
+// day) or an RFC 3339 instant. Tasks without the timestamp never match.
+func timeTerm(column string) termCompiler {
+	return func(c *taskQueryCompiler, term *taskquery.Term) (string, error) {
+		if at, err := time.Parse(time.RFC3339, term.Value); err == nil {
+			return fmt.Sprintf("%s %s %s", column, sqlOperator(term.Op), c.b.arg(at)), nil
+		}
+		day, err := time.Parse(time.DateOnly, term.Value)
+		if err != nil {
+			return "", valueError(term, "%s: expects a date (YYYY-MM-DD) or an RFC 3339 time", term.Key)
+		}
+		next := day.AddDate(0, 0, 1)
+		switch term.Op {
+		case taskquery.Lt:
+			return column + " < " + c.b.arg(day), nil
+		case taskquery.Le:
+			return column + " < " + c.b.arg(next), nil
+		case taskquery.Gt:
+			return column + " >= " + c.b.arg(next), nil
+		case taskquery.Ge:
+			return column + " >= " + c.b.arg(day), nil
+		}
+		return "(" + column + " >= " + c.b.arg(day) + " AND " + column + " < " + c.b.arg(next) + ")", nil
+	}
+}
+
+func sqlOperator(op taskquery.Op) string {
+	if op == taskquery.Eq {
+		return "="
+	}
+	return op.String()
+}
+
+func (c *taskQueryCompiler) priority(term *taskquery.Term) (string, error) {
+	priority, err := models.ParsePriority(strings.ToLower(term.Value))
+	if err != nil {
+		return "", valueError(term, "priority: expects low, medium, high or urgent")
+	}
+	return "priority " + sqlOperator(term.Op) + " " + c.b.arg(int16(priority)), nil
+}
+
+func (c *taskQueryCompiler) status(term *taskquery.Term) (string, error) {
+	if err := equalsOnly(term); err != nil {
+		return "", err
+	}
+	return "status_id IN (SELECT id FROM workflow_statuses WHERE name = " + c.b.arg(term.Value) + ")", nil
+}
+
+func (c *taskQueryCompiler) label(term *taskquery.Term) (string, error) {
+	if err := equalsOnly(term); err != nil {
+		return "", err
+	}
+	return `EXISTS (SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id
+		WHERE tl.task_id = tasks.id AND l.name = ` + c.b.arg(term.Value) + `)`, nil
+}
+
+// project matches a project by id or by name.
+func (c *taskQueryCompiler) project(term *taskquery.Term) (string, error) {
+	if err := equalsOnly(term); err != nil {
+		return "", err
+	}
+	if id, err := strconv.Atoi(term.Value); err == nil && !term.Quoted {
+		return "project_id = " + c.b.arg(id), nil
+	}
+	return "project_id IN (SELECT id FROM projects WHERE name = " + c.b.arg(term.Value) + ")", nil
+}
+
+// assignee matches tasks assigned to a user, "me" being the requesting user.
+func (c *taskQueryCompiler) assignee(term *taskquery.Term) (string, error) {
+	if err := equalsOnly(term); err != nil {
+		return "", err
+	}
+	if term.Value == "me" && !term.Quoted {
+		return "EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.task_id = tasks.id AND ta.user_id = " + c.b.arg(c.userId) + ")", nil
+	}
+	return `EXISTS (SELECT 1 FROM task_assignees ta JOIN users u ON u.id = ta.user_id
+		WHERE ta.task_id = tasks.id AND u.username = ` + c.b.arg(term.Value) + `)`, nil
+}
+
+// creator matches tasks created by a user, "me" being the requesting user.
+func (c *taskQueryCompiler) creator(term *taskquery.Term) (string, error) {
+	if err := equalsOnly(term); err != nil {
+		return "", err
+	}
+	if term.Value == "me" && !term.Quoted {
+		return "user_id = " + c.b.arg(c.userId), nil
+	}
+	return "user_id IN (SELECT id FROM users WHERE username = " + c.b.arg(term.Value) + ")", nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+	"trackerApp/pkg/rrule"
+)
+
+const (
+	defaultRecurrenceTimeZone = "UTC"
+	maxRecurrencePreview      = 100
+)
+
+const (
+	copyTaskLabels    = `INSERT INTO task_labels (task_id, label_id) SELECT $1, label_id FROM task_labels WHERE task_id = $2`
+	copyTaskAssignees = `INSERT INTO task_assignees (task_id, user_id, assigned_by, assigned_at)
+		SELECT $1, user_id, assigned_by, assigned_at FROM task_assignees WHERE task_id = $2`
+	clearTaskRecurrence = `UPDATE tasks SET recurrence = NULL, recurrence_tz = NULL WHERE id = $1`
+)
+
+func parseRecurrence(recurrence dtos.Recurrence) (*rrule.Rule, *time.Location, error) {
+	rule, err := rrule.Parse(recurrence.Rule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if recurrence.TimeZone == "" {
+		recurrence.TimeZone = defaultRecurrenceTimeZone
+	}
+	loc, err := time.LoadLocation(recurrence.TimeZone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidInput, recurrence.TimeZone)
+	}
+	return rule, loc, nil
+}
+
+// normalizeRecurrence validates a recurrence coming from a client. An empty
+// rule means "does not repeat".
+func normalizeRecurrence(recurrence *dtos.Recurrence) (*models.Recurrence, error) {
+	if recurrence == nil || recurrence.Rule == "" {
+		return nil, nil
+	}
+	rule, loc, err := parseRecurrence(*recurrence)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Recurrence{Rule: rule.String(), TimeZone: loc.String()}, nil
+}
+
+func validateRecurrence(recurrence *models.Recurrence, dueAt *time.Time) error {
+	if recurrence != nil && dueAt == nil {
+		return fmt.Errorf("%w: a recurring task needs a due_at", ErrInvalidInput)
+	}
+	return nil
+}
+
+func recurrenceColumns(recurrence *models.Recurrence) (rule, tz sql.NullString) {
+	if recurrence != nil {
+		rule = sql.NullString{String: recurrence.Rule, Valid: true}
+		tz = sql.NullString{String: recurrence.TimeZone, Valid: true}
+	}
+	return rule, tz
+}
+
+// spawnNextOccurrence creates the next instance of a completed recurring task
+// and moves the recurrence over to it, so completing the same instance twice
+// never produces duplicates. The rule is re-anchored at the new due date,
+// which is why COUNT is decreased by one. The new instance starts in the
+// initial status of the task's workflow and keeps the labels and assignees.
+// It returns the new task, or 0 once the series is over.
+func spawnNextOccurrence(tx *sql.Tx, workflow *models.Workflow, task models.Task, userId int, now time.Time) (int, error) {
+	rule, loc, err := parseRecurrence(dtos.Recurrence{Rule: task.Recurrence.Rule, TimeZone: task.Recurrence.TimeZone})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(clearTaskRecurrence, task.ID); err != nil {
+		return 0, err
+	}
+	dueAt, ok := rule.Next(task.DueAt.In(loc))
+	if !ok {
+		return 0, nil
+	}
+	var startAt *time.Time
+	if task.StartAt != nil {
+		start := dueAt.Add(task.StartAt.Sub(*task.DueAt))
+		startAt = &start
+	}
+	if rule.Count > 0 {
+		rule.Count--
+	}
+	recurrence, tz := recurrenceColumns(&models.Recurrence{Rule: rule.String(), TimeZone: task.Recurrence.TimeZone})
+	rank, err := nextRank(tx, userId, task.WorkspaceId)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	if err := tx.QueryRow(createTask, task.Title, task.Description, false, now, startAt, dueAt, task.ProjectId,
+		task.ParentTaskId, recurrence, tz, firstStatus(workflow, nil, false).ID, int16(task.Priority), rank, userId, task.WorkspaceId).Scan(&id); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(copyTaskLabels, id, task.ID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(copyTaskAssignees, id, task.ID); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *TaskService) PreviewRecurrence(recurrence dtos.Recurrence, start time.Time, n int) ([]time.Time, error) {
+	rule, loc, err := parseRecurrence(recurrence)
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 || n > maxRecurrencePreview {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidInput, maxRecurrencePreview)
+	}
+	return rule.Take(start.In(loc), n), nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+	"unicode"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+)
+
+const (
+	// the snippets are built from HTML-escaped text so that only the <mark>
+	// tags are markup
+	titleHeadline = `ts_headline('english', replace(replace(replace(title, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), q.query,
+		'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')`
+	descriptionHeadline = `ts_headline('english', replace(replace(replace(description, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), q.query,
+		'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=" ... "')`
+)
+
+// searchTerm is one part of a search query: a word, a "quoted phrase" or a
+// word* prefix, excluded from the results when preceded by a minus.
+type searchTerm struct {
+	text    string
+	phrase  bool
+	prefix  bool
+	negated bool
+}
+
+func parseSearchQuery(query string) ([]searchTerm, error) {
+	var terms []searchTerm
+	rest := strings.TrimSpace(query)
+	for rest != "" {
+		var term searchTerm
+		if rest[0] == '-' {
+			term.negated = true
+			rest = rest[1:]
+		}
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated phrase in search query", ErrInvalidInput)
+			}
+			term.text, term.phrase = rest[1:end+1], true
+			rest = rest[end+2:]
+		} else {
+			word := rest
+			rest = ""
+			if i := strings.IndexFunc(word, unicode.IsSpace); i >= 0 {
+				word, rest = word[:i], word[i:]
+			}
+			term.text = strings.TrimRight(word, "*")
+			term.prefix = term.text != word
+		}
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if strings.TrimSpace(term.text) != "" {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w: search query is empty", ErrInvalidInput)
+	}
+	for _, term := range terms {
+		if !term.negated {
+			return terms, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: search query needs a term that is not excluded", ErrInvalidInput)
+}
+
+// prefixQuery turns a word into to_tsquery syntax matching every lexeme that
+// starts with it. Only letters and digits survive, so user input can never
+// form tsquery operators.
+func prefixQuery(word string) string {
+	parts := strings.FieldsFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, " & ") + ":*"
+}
+
+// searchTsQuery compiles the terms into a tsquery expression matching tasks
+// that contain all of them.
+func searchTsQuery(b *queryBuilder, terms []searchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		var part string
+		switch {
+		case term.phrase:
+			part = "phraseto_tsquery('english', " + b.arg(term.text) + ")"
+		case term.prefix:
+			query := prefixQuery(term.text)
+			if query == "" {
+				continue
+			}
+			part = "to_tsquery('english', " + b.arg(query) + ")"
+		default:
+			part = "plainto_tsquery('english', " + b.arg(term.text) + ")"
+		}
+		if term.negated {
+			part = "!!" + part
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " && ")
+}
+
+// Search finds the tasks whose title or description match a query, best
+// matches first. Matches in the title weigh more than in the description.
+func (s *TaskService) Search(userId int, search dtos.TaskSearch) (*models.SearchPage, error) {
+	terms, err := parseSearchQuery(search.Query)
+	if err != nil {
+		return nil, err
+	}
+	limit := search.Limit
+	if limit <= 0 {
+		limit = defaultSearchPageSize
+	}
+	limit = min(limit, maxSearchPageSize)
+	if search.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", ErrInvalidInput)
+	}
+	if err := checkTaskScope(s.db, userId, search.WorkspaceId); err != nil {
+		return nil, err
+	}
+
+	var b queryBuilder
+	tsquery := searchTsQuery(&b, terms)
+	if tsquery == "" {
+		return nil, fmt.Errorf("%w: search query is empty", ErrInvalidInput)
+	}
+	whereTaskScope(&b, userId, search.WorkspaceId)
+	b.where("deleted_at IS NULL")
+	b.where("search_vector @@ q.query")
+	from := ` FROM tasks, (SELECT ` + tsquery + ` AS query) q` + b.whereClause()
+
+	page := models.SearchPage{Results: []models.SearchResult{}}
+	if err := s.db.QueryRow(`SELECT COUNT(*)`+from, b.args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`SELECT %s, ts_rank_cd(search_vector, q.query) AS search_rank, %s, %s%s
+		ORDER BY search_rank DESC, id LIMIT %s OFFSET %s`,
+		taskColumns, titleHeadline, descriptionHeadline, from, b.arg(limit), b.arg(search.Offset))
+	rows, err := s.db.Query(query, b.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	var results []models.SearchResult
+	for rows.Next() {
+		var result models.SearchResult
+		task, err := scanTask(extraColumns{row: rows, dest: []any{&result.Rank, &result.TitleSnippet, &result.DescriptionSnippet}})
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadTaskDetails(s.db, tasks); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Task = tasks[i]
+	}
+	if results != nil {
+		page.Results = results
+	}
+	return &page, nil
+}
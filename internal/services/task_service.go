@@ -2,78 +2,418 @@ package services
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 	"trackerApp/internal/models"
 	"trackerApp/internal/services/dtos"
+	"trackerApp/pkg/storage"
 )
 
 type TaskService struct {
-	db *sql.DB
+	db    *sql.DB
+	store storage.Store
 }
 
-func NewTaskService(db *sql.DB) *TaskService {
-	return &TaskService{db: db}
+func NewTaskService(db *sql.DB, store storage.Store) *TaskService {
+	return &TaskService{db: db, store: store}
 }
 
+const taskColumns = `id, title, description, is_complete, create_at, updated_at, completed_at, start_at, due_at, project_id, parent_task_id, workspace_id,
+	recurrence, recurrence_tz, status_id, (SELECT ws.name FROM workflow_statuses ws WHERE ws.id = tasks.status_id), priority,
+	(SELECT COUNT(*) FROM comments c WHERE c.task_id = tasks.id)`
+
 const (
-	getTasks       = `SELECT id, title, description, is_complete, create_at FROM tasks WHERE user_id = $1`
-	getTaskById    = `SELECT id, title, description, is_complete, create_at FROM tasks WHERE id = $1 AND user_id = $2`
-	createTask     = `INSERT INTO tasks (title,description,is_complete,create_at, user_id) VALUES($1,$2,$3,$4,$5) RETURNING id`
-	updateTaskById = `UPDATE tasks SET title=$1, description=$2, is_complete=$3 WHERE id=$4 AND user_id=$5`
-	deleteTaskById = `DELETE FROM tasks WHERE id=$1 AND user_id=$2`
+	getTaskById      = `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	getOverdue       = `SELECT ` + taskColumns + ` FROM tasks WHERE ` + inTaskScope + ` AND deleted_at IS NULL AND is_complete = FALSE AND due_at < $3 ORDER BY due_at`
+	getDueBetween    = `SELECT ` + taskColumns + ` FROM tasks WHERE ` + inTaskScope + ` AND deleted_at IS NULL AND due_at >= $3 AND due_at < $4 ORDER BY due_at`
+	getTaskForUpdate = getTaskById + ` FOR UPDATE`
+	createTask       = `INSERT INTO tasks (title,description,is_complete,create_at,updated_at,start_at,due_at,project_id,parent_task_id,
+		recurrence,recurrence_tz,status_id,priority,rank,user_id,workspace_id) VALUES($1,$2,$3,$4,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15) RETURNING id`
+	updateTaskById = `UPDATE tasks SET title=$1, description=$2, is_complete=$3, start_at=$4, due_at=$5, updated_at=$6,
+		completed_at = CASE WHEN $3 THEN COALESCE(completed_at, $6) ELSE NULL END,
+		recurrence=$7, recurrence_tz=$8, status_id=$9, priority=$10
+		WHERE id=$11 AND user_id=$12`
+	getTaskLabels = `SELECT tl.task_id, l.id, l.name, l.color FROM task_labels tl JOIN labels l ON l.id = tl.label_id
+		WHERE tl.task_id = ANY($1) ORDER BY l.name`
+	getTaskAssignees = `SELECT a.task_id, a.user_id, u.username, b.username, a.assigned_at FROM task_assignees a
+		JOIN users u ON u.id = a.user_id LEFT JOIN users b ON b.id = a.assigned_by
+		WHERE a.task_id = ANY($1) ORDER BY u.username`
+	// subtasks go to the trash with their parent and share its deleted_at, so
+	// that restoring the parent brings back exactly those
+	trashTaskById = `WITH RECURSIVE subtree AS (
+			SELECT id FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			UNION
+			SELECT t.id FROM tasks t JOIN subtree s ON t.parent_task_id = s.id WHERE t.deleted_at IS NULL
+		)
+		UPDATE tasks SET deleted_at = $3 WHERE id IN (SELECT id FROM subtree) RETURNING ` + taskColumns
 )
 
-func (s *TaskService) Get(userId int) ([]models.Task, error) {
-	var tasks []models.Task
-	rows, err := s.db.Query(getTasks, userId)
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row rowScanner) (models.Task, error) {
+	var task models.Task
+	var recurrence, recurrenceTz, statusName sql.NullString
+	var statusId sql.NullInt64
+	err := row.Scan(&task.ID, &task.Title, &task.Description, &task.IsCompleted, &task.CreateAt, &task.UpdatedAt, &task.CompletedAt,
+		&task.StartAt, &task.DueAt, &task.ProjectId, &task.ParentTaskId, &task.WorkspaceId, &recurrence, &recurrenceTz, &statusId, &statusName, &task.Priority,
+		&task.CommentCount)
+	if recurrence.Valid {
+		task.Recurrence = &models.Recurrence{Rule: recurrence.String, TimeZone: recurrenceTz.String}
+	}
+	if statusId.Valid {
+		task.Status = &models.TaskStatus{ID: int(statusId.Int64), Name: statusName.String}
+	}
+	return task, err
+}
+
+func queryTasks(q querier, query string, args ...any) ([]models.Task, error) {
+	tasks := []models.Task{}
+	rows, err := q.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var task models.Task
-		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.IsCompleted, &task.CreateAt); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			continue
 		}
 		tasks = append(tasks, task)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadTaskDetails(q, tasks); err != nil {
+		return nil, err
+	}
 	return tasks, nil
 }
 
+// loadTaskDetails fills in the labels and assignees of all given tasks.
+func loadTaskDetails(q querier, tasks []models.Task) error {
+	if err := loadLabels(q, tasks); err != nil {
+		return err
+	}
+	return loadAssignees(q, tasks)
+}
+
+// loadLabels fills in the labels of all given tasks with a single query.
+func loadLabels(q querier, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]int, len(tasks))
+	byId := make(map[int]*models.Task, len(tasks))
+	for i := range tasks {
+		tasks[i].Labels = []models.Label{}
+		ids[i] = tasks[i].ID
+		byId[tasks[i].ID] = &tasks[i]
+	}
+	rows, err := q.Query(getTaskLabels, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskId int
+		var label models.Label
+		if err := rows.Scan(&taskId, &label.ID, &label.Name, &label.Color); err != nil {
+			return err
+		}
+		if task, ok := byId[taskId]; ok {
+			task.Labels = append(task.Labels, label)
+		}
+	}
+	return rows.Err()
+}
+
+// loadAssignees fills in the assignees of all given tasks with a single query.
+func loadAssignees(q querier, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]int, len(tasks))
+	byId := make(map[int]*models.Task, len(tasks))
+	for i := range tasks {
+		tasks[i].Assignees = []models.Assignee{}
+		ids[i] = tasks[i].ID
+		byId[tasks[i].ID] = &tasks[i]
+	}
+	rows, err := q.Query(getTaskAssignees, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskId int
+		var assignee models.Assignee
+		if err := rows.Scan(&taskId, &assignee.UserId, &assignee.Username, &assignee.AssignedBy, &assignee.AssignedAt); err != nil {
+			return err
+		}
+		if task, ok := byId[taskId]; ok {
+			task.Assignees = append(task.Assignees, assignee)
+		}
+	}
+	return rows.Err()
+}
+
+func parsePriority(name string) (models.Priority, error) {
+	priority, err := models.ParsePriority(name)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return priority, nil
+}
+
+func validateSchedule(startAt, dueAt *time.Time) error {
+	if startAt != nil && dueAt != nil && startAt.After(*dueAt) {
+		return fmt.Errorf("%w: start_at must not be after due_at", ErrInvalidInput)
+	}
+	return nil
+}
+
 func (s *TaskService) GetById(taskId, userId int) (*models.Task, error) {
-	var task models.Task
-	if err := s.db.QueryRow(getTaskById, taskId, userId).Scan(&task.ID, &task.Title, &task.Description, &task.IsCompleted, &task.CreateAt); err != nil {
+	ownerId, err := authorizeTask(s.db, taskId, userId, accessView)
+	if err != nil {
 		return nil, err
 	}
-	return &task, nil
+	task, err := scanTask(s.db.QueryRow(getTaskById, taskId, ownerId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	tasks := []models.Task{task}
+	if err := loadTaskDetails(s.db, tasks); err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
+}
+
+func (s *TaskService) GetOverdue(userId int, workspaceId *int, now time.Time) ([]models.Task, error) {
+	if err := checkTaskScope(s.db, userId, workspaceId); err != nil {
+		return nil, err
+	}
+	return queryTasks(s.db, getOverdue, userId, workspaceId, now)
+}
+
+func (s *TaskService) GetDueBetween(userId int, workspaceId *int, from, to time.Time) ([]models.Task, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: empty due date range", ErrInvalidInput)
+	}
+	if err := checkTaskScope(s.db, userId, workspaceId); err != nil {
+		return nil, err
+	}
+	return queryTasks(s.db, getDueBetween, userId, workspaceId, from, to)
 }
 
 func (s *TaskService) Create(userId int, taskDto dtos.CreateTask) (int, error) {
+	if err := validateSchedule(taskDto.StartAt, taskDto.DueAt); err != nil {
+		return 0, err
+	}
+	recurrence, err := normalizeRecurrence(taskDto.Recurrence)
+	if err != nil {
+		return 0, err
+	}
+	if err := validateRecurrence(recurrence, taskDto.DueAt); err != nil {
+		return 0, err
+	}
+	if taskDto.WorkspaceId != nil {
+		if _, err := checkWorkspaceRole(s.db, *taskDto.WorkspaceId, userId, models.WorkspaceMember); err != nil {
+			return 0, err
+		}
+	}
+	// a task created in a shared project belongs to the project's owner
+	ownerId := userId
+	if taskDto.ProjectId != nil {
+		if taskDto.WorkspaceId != nil {
+			return 0, fmt.Errorf("%w: workspace tasks cannot be put into projects", ErrInvalidInput)
+		}
+		if ownerId, err = checkProjectWritable(s.db, *taskDto.ProjectId, userId); err != nil {
+			return 0, err
+		}
+	}
+	if taskDto.ParentTaskId != nil {
+		if err := checkParentTask(s.db, *taskDto.ParentTaskId, userId, ownerId, taskDto.WorkspaceId); err != nil {
+			return 0, err
+		}
+	}
+	priority := models.PriorityMedium
+	if taskDto.Priority != "" {
+		if priority, err = parsePriority(taskDto.Priority); err != nil {
+			return 0, err
+		}
+	}
+	workflow, err := resolveWorkflow(s.db, ownerId, taskDto.ProjectId)
+	if err != nil {
+		return 0, err
+	}
+	status, err := initialStatus(workflow, taskDto.StatusId)
+	if err != nil {
+		return 0, err
+	}
+	rank, err := nextRank(s.db, ownerId, taskDto.WorkspaceId)
+	if err != nil {
+		return 0, err
+	}
 	task := models.Task{
-		Title:       taskDto.Title,
-		Description: taskDto.Description,
-		IsCompleted: false,
-		CreateAt:    time.Now().Format("02/01/2006"),
+		Title:        taskDto.Title,
+		Description:  taskDto.Description,
+		IsCompleted:  false,
+		CreateAt:     time.Now(),
+		StartAt:      taskDto.StartAt,
+		DueAt:        taskDto.DueAt,
+		ProjectId:    taskDto.ProjectId,
+		ParentTaskId: taskDto.ParentTaskId,
+		WorkspaceId:  taskDto.WorkspaceId,
+		Recurrence:   recurrence,
+		Priority:     priority,
+	}
+	recurrenceRule, recurrenceTz := recurrenceColumns(task.Recurrence)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
 	}
+	defer tx.Rollback()
 
 	var id int
-	if err := s.db.QueryRow(createTask, task.Title, task.Description, task.IsCompleted, task.CreateAt, userId).Scan(&id); err != nil {
+	if err := tx.QueryRow(createTask, task.Title, task.Description, task.IsCompleted, task.CreateAt, task.StartAt, task.DueAt, task.ProjectId,
+		task.ParentTaskId, recurrenceRule, recurrenceTz, status.ID, int16(task.Priority), rank, ownerId, task.WorkspaceId).Scan(&id); err != nil {
 		return 0, err
 	}
-	return id, nil
+	task.Status = &models.TaskStatus{ID: status.ID, Name: status.Name}
+	if err := recordTaskChange(tx, id, userId, models.TaskCreated, nil, &task, task.CreateAt); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
 }
 
+// Update changes a task on behalf of its owner or an editor it is shared
+// with. The owner's workflow applies either way.
 func (s *TaskService) Update(taskId, userId int, updateTask dtos.UpdateTask) error {
-	if _, err := s.db.Exec(updateTaskById, updateTask.Title, updateTask.Description, updateTask.IsComplete, taskId, userId); err != nil {
+	if err := validateSchedule(updateTask.StartAt, updateTask.DueAt); err != nil {
 		return err
 	}
-	return nil
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ownerId, err := authorizeTask(tx, taskId, userId, accessEdit)
+	if err != nil {
+		return err
+	}
+	current, err := scanTask(tx.QueryRow(getTaskForUpdate, taskId, ownerId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	recurrence := current.Recurrence
+	if updateTask.Recurrence != nil {
+		if recurrence, err = normalizeRecurrence(updateTask.Recurrence); err != nil {
+			return err
+		}
+	}
+	if err := validateRecurrence(recurrence, updateTask.DueAt); err != nil {
+		return err
+	}
+
+	priority := current.Priority
+	if updateTask.Priority != nil {
+		if priority, err = parsePriority(*updateTask.Priority); err != nil {
+			return err
+		}
+	}
+	// is_complete follows the status, so clients unaware of workflows keep working
+	workflow, err := resolveWorkflow(tx, ownerId, current.ProjectId)
+	if err != nil {
+		return err
+	}
+	status, err := nextTaskStatus(workflow, current, updateTask.StatusId, updateTask.IsComplete)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	completing := status.IsTerminal && !current.IsCompleted
+	if completing {
+		if !updateTask.Force {
+			if err := checkBlockers(tx, taskId); err != nil {
+				return err
+			}
+		}
+		if err := completeSubtasks(tx, taskId, ownerId, userId, updateTask.OnOpenSubtasks, now); err != nil {
+			return err
+		}
+	}
+	recurrenceRule, recurrenceTz := recurrenceColumns(recurrence)
+	if _, err := tx.Exec(updateTaskById, updateTask.Title, updateTask.Description, status.IsTerminal, updateTask.StartAt, updateTask.DueAt,
+		now, recurrenceRule, recurrenceTz, status.ID, int16(priority), taskId, ownerId); err != nil {
+		return err
+	}
+	var nextId int
+	if completing && recurrence != nil {
+		updated := current
+		updated.Title, updated.Description = updateTask.Title, updateTask.Description
+		updated.StartAt, updated.DueAt = updateTask.StartAt, updateTask.DueAt
+		updated.Recurrence, updated.Priority = recurrence, priority
+		if nextId, err = spawnNextOccurrence(tx, workflow, updated, ownerId, now); err != nil {
+			return err
+		}
+	}
+	updated, err := scanTask(tx.QueryRow(getTaskById, taskId, ownerId))
+	if err != nil {
+		return err
+	}
+	if err := recordTaskChange(tx, taskId, userId, models.TaskUpdated, &current, &updated, now); err != nil {
+		return err
+	}
+	if nextId != 0 {
+		next, err := scanTask(tx.QueryRow(getTaskById, nextId, ownerId))
+		if err != nil {
+			return err
+		}
+		if err := recordTaskChange(tx, nextId, userId, models.TaskCreated, nil, &next, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
+// Delete moves a task with its subtasks to the trash, where they stay
+// restorable until purged. Only the owner, or a workspace admin, may delete a
+// task.
 func (s *TaskService) Delete(taskId, userId int) error {
-	if _, err := s.db.Exec(deleteTaskById, taskId, userId); err != nil {
+	tx, err := s.db.Begin()
+	if err != nil {
 		return err
 	}
-	return nil
+	defer tx.Rollback()
+
+	ownerId, err := authorizeTask(tx, taskId, userId, accessOwner)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	trashed, err := queryTasks(tx, trashTaskById, taskId, ownerId, now)
+	if err != nil {
+		return err
+	}
+	for i := range trashed {
+		if err := recordTaskChange(tx, trashed[i].ID, userId, models.TaskDeleted, &trashed[i], nil, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"time"
+	"trackerApp/internal/models"
+)
+
+const (
+	// subtasks deleted with their parent are listed and restored through it
+	getTrash = `SELECT ` + taskColumns + `, deleted_at FROM tasks
+		WHERE ` + inTaskScope + ` AND deleted_at IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM tasks p WHERE p.id = tasks.parent_task_id AND p.deleted_at IS NOT NULL)
+		ORDER BY deleted_at DESC, id`
+	isParentTrashed = `SELECT EXISTS (SELECT 1 FROM tasks t JOIN tasks p ON p.id = t.parent_task_id
+		WHERE t.id = $1 AND p.deleted_at IS NOT NULL)`
+	restoreTaskById = `WITH RECURSIVE subtree AS (
+			SELECT id, deleted_at FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+			UNION
+			SELECT t.id, t.deleted_at FROM tasks t JOIN subtree s ON t.parent_task_id = s.id WHERE t.deleted_at = s.deleted_at
+		)
+		UPDATE tasks SET deleted_at = NULL WHERE id IN (SELECT id FROM subtree) RETURNING ` + taskColumns
+	purgeTaskById = `WITH RECURSIVE subtree AS (
+			SELECT id FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+			UNION
+			SELECT t.id FROM tasks t JOIN subtree s ON t.parent_task_id = s.id
+		)
+		DELETE FROM tasks WHERE id IN (SELECT id FROM subtree) RETURNING ` + taskColumns
+	getExpiredBlobKeys = `SELECT a.storage_key FROM attachments a JOIN tasks t ON t.id = a.task_id WHERE t.deleted_at < $1`
+	// expired tasks are purged by the tracker itself, hence no actor
+	purgeExpiredTasks = `WITH purged AS (DELETE FROM tasks WHERE deleted_at < $1 RETURNING id)
+		INSERT INTO task_history (task_id, action, create_at) SELECT id, 'purged', $2 FROM purged`
+)
+
+// GetTrash lists the deleted tasks of a workspace, or the user's personal
+// ones, most recently deleted first.
+func (s *TaskService) GetTrash(userId int, workspaceId *int) ([]models.TrashedTask, error) {
+	if err := checkTaskScope(s.db, userId, workspaceId); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(getTrash, userId, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	var deletedAt []time.Time
+	for rows.Next() {
+		var at time.Time
+		task, err := scanTask(extraColumns{row: rows, dest: []any{&at}})
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+		deletedAt = append(deletedAt, at)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadTaskDetails(s.db, tasks); err != nil {
+		return nil, err
+	}
+	trash := make([]models.TrashedTask, len(tasks))
+	for i, task := range tasks {
+		trash[i] = models.TrashedTask{Task: task, DeletedAt: deletedAt[i]}
+	}
+	return trash, nil
+}
+
+// Restore takes a task out of the trash together with the subtasks that
+// were deleted with it. A subtask cannot be restored while its parent is in
+// the trash.
+func (s *TaskService) Restore(taskId, userId int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ownerId, err := authorizeTrashedTask(tx, taskId, userId, accessOwner)
+	if err != nil {
+		return err
+	}
+	var parentTrashed bool
+	if err := tx.QueryRow(isParentTrashed, taskId).Scan(&parentTrashed); err != nil {
+		return err
+	}
+	if parentTrashed {
+		return fmt.Errorf("%w: the parent task is in the trash, restore it first", ErrConflict)
+	}
+	restored, err := queryTasks(tx, restoreTaskById, taskId, ownerId)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range restored {
+		if err := recordTaskChange(tx, restored[i].ID, userId, models.TaskRestored, nil, &restored[i], now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Purge deletes a task in the trash for good, with its subtasks and the
+// contents of their attachments.
+func (s *TaskService) Purge(taskId, userId int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ownerId, err := authorizeTrashedTask(tx, taskId, userId, accessOwner)
+	if err != nil {
+		return err
+	}
+	keys, err := getBlobKeys(tx, getTaskBlobKeys, taskId, ownerId)
+	if err != nil {
+		return err
+	}
+	purged, err := queryTasks(tx, purgeTaskById, taskId, ownerId)
+	if err != nil {
+		return err
+	}
+	if len(purged) == 0 {
+		return ErrNotFound
+	}
+	now := time.Now()
+	for i := range purged {
+		if err := recordTaskChange(tx, purged[i].ID, userId, models.TaskPurged, &purged[i], nil, now); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	deleteBlobs(s.store, keys)
+	return nil
+}
+
+// PurgeTrash purges the tasks of all users that were deleted before the
+// given time and returns how many there were.
+func (s *TaskService) PurgeTrash(before time.Time) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	keys, err := getBlobKeys(tx, getExpiredBlobKeys, before)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(purgeExpiredTasks, before, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	deleteBlobs(s.store, keys)
+	return int(purged), nil
+}
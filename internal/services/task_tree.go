@@ -0,0 +1,216 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"trackerApp/internal/models"
+)
+
+// What happens when a task with open subtasks is completed.
+const (
+	OpenSubtasksRefuse   = "refuse"
+	OpenSubtasksComplete = "complete"
+)
+
+const (
+	getSubtree = `WITH RECURSIVE subtree AS (
+			SELECT id FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			UNION
+			SELECT t.id FROM tasks t JOIN subtree s ON t.parent_task_id = s.id WHERE t.deleted_at IS NULL
+		)
+		SELECT ` + taskColumns + ` FROM tasks WHERE id IN (SELECT id FROM subtree) ORDER BY create_at, id`
+	isAncestorOf = `WITH RECURSIVE ancestors AS (
+			SELECT id, parent_task_id FROM tasks WHERE id = $1
+			UNION
+			SELECT t.id, t.parent_task_id FROM tasks t JOIN ancestors a ON t.id = a.parent_task_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`
+	countOpenDescendants = `WITH RECURSIVE descendants AS (
+			SELECT id, is_complete FROM tasks WHERE parent_task_id = $1 AND deleted_at IS NULL
+			UNION
+			SELECT t.id, t.is_complete FROM tasks t JOIN descendants d ON t.parent_task_id = d.id WHERE t.deleted_at IS NULL
+		)
+		SELECT COUNT(*) FROM descendants WHERE is_complete = FALSE`
+	getOpenDescendants = `WITH RECURSIVE descendants AS (
+			SELECT id FROM tasks WHERE parent_task_id = $1 AND deleted_at IS NULL
+			UNION
+			SELECT t.id FROM tasks t JOIN descendants d ON t.parent_task_id = d.id WHERE t.deleted_at IS NULL
+		)
+		SELECT ` + taskColumns + ` FROM tasks WHERE id IN (SELECT id FROM descendants) AND is_complete = FALSE`
+	getParentScope    = `SELECT user_id, workspace_id FROM tasks WHERE id = $1 FOR UPDATE`
+	setTaskParentById = `UPDATE tasks SET parent_task_id=$1, updated_at=$2 WHERE id=$3 AND user_id=$4`
+	// serializes changes of the task tree, so that two concurrent moves cannot
+	// close a cycle that neither of them sees on its own
+	lockTaskTree = `SELECT pg_advisory_xact_lock(hashtext('task_tree'))`
+)
+
+// checkParentTask verifies that the user may edit the parent and that it is
+// in the scope of the subtask, which belongs to ownerId.
+func checkParentTask(q querier, parentId, userId, ownerId int, workspaceId *int) error {
+	if _, err := authorizeTask(q, parentId, userId, accessEdit); errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: parent task %d does not exist", ErrInvalidInput, parentId)
+	} else if err != nil {
+		return err
+	}
+	var parentOwnerId int
+	var parentWorkspaceId *int
+	if err := q.QueryRow(getParentScope, parentId).Scan(&parentOwnerId, &parentWorkspaceId); err != nil {
+		return err
+	}
+	sameScope := workspaceId == nil && parentWorkspaceId == nil && parentOwnerId == ownerId ||
+		workspaceId != nil && parentWorkspaceId != nil && *workspaceId == *parentWorkspaceId
+	if !sameScope {
+		return fmt.Errorf("%w: parent task %d is not in the scope of the task", ErrInvalidInput, parentId)
+	}
+	return nil
+}
+
+// completeSubtasks applies the open subtasks policy before a task is completed.
+// Completed subtasks move to the first terminal status of their workflow; the
+// actor is recorded in their history.
+func completeSubtasks(tx *sql.Tx, taskId, userId, actorId int, policy string, now time.Time) error {
+	switch policy {
+	case "", OpenSubtasksRefuse:
+		var open int
+		if err := tx.QueryRow(countOpenDescendants, taskId).Scan(&open); err != nil {
+			return err
+		}
+		if open > 0 {
+			return fmt.Errorf("%w: task has %d open subtasks", ErrConflict, open)
+		}
+		return nil
+	case OpenSubtasksComplete:
+		tasks, err := queryTasks(tx, getOpenDescendants, taskId)
+		if err != nil {
+			return err
+		}
+		workflows := make(map[int]*models.Workflow)
+		for _, task := range tasks {
+			projectKey := 0
+			if task.ProjectId != nil {
+				projectKey = *task.ProjectId
+			}
+			workflow, ok := workflows[projectKey]
+			if !ok {
+				if workflow, err = resolveWorkflow(tx, userId, task.ProjectId); err != nil {
+					return err
+				}
+				workflows[projectKey] = workflow
+			}
+			status, err := nextTaskStatus(workflow, task, nil, true)
+			if err != nil {
+				// the parent's completion wins over the subtask's transitions
+				status = firstStatus(workflow, nil, true)
+			}
+			if _, err := tx.Exec(setTaskStatus, status.ID, true, now, task.ID); err != nil {
+				return err
+			}
+			completed := task
+			completed.IsCompleted, completed.Status = true, &models.TaskStatus{ID: status.ID, Name: status.Name}
+			if err := recordTaskChange(tx, task.ID, actorId, models.TaskUpdated, &task, &completed, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown open subtasks policy %q", ErrInvalidInput, policy)
+	}
+}
+
+func (s *TaskService) SetParent(taskId, userId int, parentId *int) error {
+	if parentId != nil && *parentId == taskId {
+		return fmt.Errorf("%w: a task cannot be its own parent", ErrInvalidInput)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ownerId, err := authorizeTask(tx, taskId, userId, accessOwner)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(lockTaskTree); err != nil {
+		return err
+	}
+	current, err := scanTask(tx.QueryRow(getTaskForUpdate, taskId, ownerId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if parentId != nil {
+		if err := checkParentTask(tx, *parentId, userId, ownerId, current.WorkspaceId); err != nil {
+			return err
+		}
+		var cycle bool
+		if err := tx.QueryRow(isAncestorOf, *parentId, taskId).Scan(&cycle); err != nil {
+			return err
+		}
+		if cycle {
+			return fmt.Errorf("%w: task %d is a subtask of task %d", ErrInvalidInput, *parentId, taskId)
+		}
+	}
+	now := time.Now()
+	if _, err := tx.Exec(setTaskParentById, parentId, now, taskId, ownerId); err != nil {
+		return err
+	}
+	updated := current
+	updated.ParentTaskId = parentId
+	if err := recordTaskChange(tx, taskId, userId, models.TaskUpdated, &current, &updated, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *TaskService) GetSubtree(taskId, userId int) (*models.TaskNode, error) {
+	ownerId, err := authorizeTask(s.db, taskId, userId, accessView)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := queryTasks(s.db, getSubtree, taskId, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[int]*models.TaskNode, len(tasks))
+	for _, task := range tasks {
+		nodes[task.ID] = &models.TaskNode{Task: task, Subtasks: []*models.TaskNode{}}
+	}
+	root, ok := nodes[taskId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	for _, task := range tasks {
+		if task.ID == taskId || task.ParentTaskId == nil {
+			continue
+		}
+		if parent, ok := nodes[*task.ParentTaskId]; ok {
+			parent.Subtasks = append(parent.Subtasks, nodes[task.ID])
+		}
+	}
+	rollUpProgress(root)
+	return root, nil
+}
+
+// rollUpProgress sets the percentage of completed descendants on every node
+// and returns the number of completed and total descendants of the node.
+func rollUpProgress(node *models.TaskNode) (completed, total int) {
+	for _, child := range node.Subtasks {
+		c, t := rollUpProgress(child)
+		completed, total = completed+c, total+t+1
+		if child.IsCompleted {
+			completed++
+		}
+	}
+	switch {
+	case total > 0:
+		node.Progress = completed * 100 / total
+	case node.IsCompleted:
+		node.Progress = 100
+	}
+	return completed, total
+}
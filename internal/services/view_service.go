@@ -0,0 +1,226 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+)
+
+type ViewService struct {
+	db    *sql.DB
+	tasks *TaskService
+}
+
+func NewViewService(db *sql.DB, tasks *TaskService) *ViewService {
+	return &ViewService{db: db, tasks: tasks}
+}
+
+const maxViewNameLength = 255
+
+const (
+	viewColumns    = `id, name, filter, sort, sort_order, is_default, create_at, updated_at`
+	getViews       = `SELECT ` + viewColumns + ` FROM saved_views WHERE user_id = $1 ORDER BY name`
+	getViewById    = `SELECT ` + viewColumns + ` FROM saved_views WHERE id = $1 AND user_id = $2`
+	getDefaultView = `SELECT ` + viewColumns + ` FROM saved_views WHERE user_id = $1 AND is_default`
+	createView     = `INSERT INTO saved_views (user_id, name, filter, sort, sort_order, is_default, create_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7) RETURNING id`
+	updateViewById = `UPDATE saved_views SET name=$1, filter=$2, sort=$3, sort_order=$4, is_default=$5, updated_at=$6
+		WHERE id=$7 AND user_id=$8`
+	deleteViewById   = `DELETE FROM saved_views WHERE id=$1 AND user_id=$2`
+	clearDefaultView = `UPDATE saved_views SET is_default = FALSE WHERE user_id = $1 AND is_default AND id <> $2`
+)
+
+func scanView(row rowScanner) (*models.SavedView, error) {
+	var view models.SavedView
+	var filter []byte
+	err := row.Scan(&view.ID, &view.Name, &filter, &view.Sort, &view.Order, &view.IsDefault, &view.CreateAt, &view.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(filter, &view.Filter); err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// viewTaskFilter turns a saved view into the task list filter it stands for,
+// keeping the paging and scope of the given filter.
+func viewTaskFilter(view *models.SavedView, page dtos.TaskFilter) dtos.TaskFilter {
+	f := view.Filter
+	return dtos.TaskFilter{
+		Completed:    f.Completed,
+		Title:        f.Title,
+		Description:  f.Description,
+		CreatedFrom:  f.CreatedFrom,
+		CreatedTo:    f.CreatedTo,
+		Labels:       f.Labels,
+		ProjectId:    f.ProjectId,
+		Inbox:        f.Inbox,
+		ParentId:     f.ParentId,
+		Status:       f.Status,
+		Priority:     f.Priority,
+		AssignedToMe: f.AssignedToMe,
+		CreatedByMe:  f.CreatedByMe,
+		Unassigned:   f.Unassigned,
+		Query:        f.Query,
+		Sort:         view.Sort,
+		Order:        view.Order,
+		Limit:        page.Limit,
+		Cursor:       page.Cursor,
+		WorkspaceId:  page.WorkspaceId,
+	}
+}
+
+// hasCriteria reports whether a task list filter asks for anything besides
+// paging, i.e. whether the default view stays out of the way.
+func hasCriteria(filter dtos.TaskFilter) bool {
+	filter.Limit, filter.Cursor, filter.WorkspaceId = 0, "", nil
+	return !reflect.DeepEqual(filter, dtos.TaskFilter{})
+}
+
+// validateView normalizes a view and checks its criteria the way the task
+// list would, so that a saved view never fails when it is executed.
+func validateView(userId int, form dtos.ViewForm) (dtos.ViewForm, []byte, error) {
+	form.Name = strings.TrimSpace(form.Name)
+	if form.Name == "" {
+		return form, nil, fmt.Errorf("%w: view name is required", ErrInvalidInput)
+	}
+	if len(form.Name) > maxViewNameLength {
+		return form, nil, fmt.Errorf("%w: view name is longer than %d characters", ErrInvalidInput, maxViewNameLength)
+	}
+	if form.Sort == "" {
+		form.Sort = "created"
+	}
+	if form.Order == "" {
+		form.Order = "asc"
+	}
+	if _, ok := taskSortKeys[form.Sort]; !ok {
+		return form, nil, fmt.Errorf("%w: unknown sort key %q", ErrInvalidInput, form.Sort)
+	}
+	if form.Order != "asc" && form.Order != "desc" {
+		return form, nil, fmt.Errorf("%w: order must be asc or desc", ErrInvalidInput)
+	}
+	form.Filter.Query = strings.TrimSpace(form.Filter.Query)
+	var b queryBuilder
+	view := &models.SavedView{Filter: form.Filter, Sort: form.Sort, Order: form.Order}
+	if err := applyTaskFilter(&b, userId, viewTaskFilter(view, dtos.TaskFilter{})); err != nil {
+		return form, nil, err
+	}
+	filter, err := json.Marshal(form.Filter)
+	if err != nil {
+		return form, nil, err
+	}
+	return form, filter, nil
+}
+
+func (s *ViewService) Get(userId int) ([]models.SavedView, error) {
+	views := []models.SavedView{}
+	rows, err := s.db.Query(getViews, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		view, err := scanView(rows)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *view)
+	}
+	return views, rows.Err()
+}
+
+func (s *ViewService) GetById(viewId, userId int) (*models.SavedView, error) {
+	return scanView(s.db.QueryRow(getViewById, viewId, userId))
+}
+
+// Create saves a view. A new default view replaces the previous one.
+func (s *ViewService) Create(userId int, form dtos.ViewForm) (int, error) {
+	form, filter, err := validateView(userId, form)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if form.IsDefault {
+		if _, err := tx.Exec(clearDefaultView, userId, 0); err != nil {
+			return 0, err
+		}
+	}
+	var id int
+	err = tx.QueryRow(createView, userId, form.Name, filter, form.Sort, form.Order, form.IsDefault, time.Now()).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%w: view %q already exists", ErrConflict, form.Name)
+		}
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// Update replaces a view. Making it the default view replaces the previous one.
+func (s *ViewService) Update(viewId, userId int, form dtos.ViewForm) error {
+	form, filter, err := validateView(userId, form)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if form.IsDefault {
+		if _, err := tx.Exec(clearDefaultView, userId, viewId); err != nil {
+			return err
+		}
+	}
+	res, err := tx.Exec(updateViewById, form.Name, filter, form.Sort, form.Order, form.IsDefault, time.Now(), viewId, userId)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: view %q already exists", ErrConflict, form.Name)
+		}
+		return err
+	}
+	if err := expectAffected(res); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *ViewService) Delete(viewId, userId int) error {
+	res, err := s.db.Exec(deleteViewById, viewId, userId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// GetTasks lists a page of the tasks matching a view in the given workspace,
+// or among the user's personal tasks.
+func (s *ViewService) GetTasks(viewId, userId int, workspaceId *int, page dtos.ViewPage) (*models.TaskPage, error) {
+	view, err := s.GetById(viewId, userId)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.tasks.Get(userId, viewTaskFilter(view, dtos.TaskFilter{Limit: page.Limit, Cursor: page.Cursor, WorkspaceId: workspaceId}))
+	if err != nil {
+		return nil, err
+	}
+	tasks.ViewId = &view.ID
+	return tasks, nil
+}
@@ -0,0 +1,421 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+)
+
+type WorkflowService struct {
+	db *sql.DB
+}
+
+func NewWorkflowService(db *sql.DB) *WorkflowService {
+	return &WorkflowService{db: db}
+}
+
+// defaultWorkflow is created for every user on first use. All transitions
+// between its statuses are allowed.
+var defaultWorkflow = []dtos.WorkflowStatus{
+	{Name: "Backlog"},
+	{Name: "In Progress"},
+	{Name: "Review"},
+	{Name: "Done", IsTerminal: true},
+}
+
+const maxStatusNameLength = 64
+
+const (
+	getProjectStatuses = `SELECT id, name, position, is_terminal FROM workflow_statuses WHERE project_id = $1 ORDER BY position`
+	getDefaultStatuses = `SELECT id, name, position, is_terminal FROM workflow_statuses
+		WHERE user_id = $1 AND project_id IS NULL ORDER BY position`
+	getTransitions = `SELECT from_status_id, to_status_id FROM workflow_transitions
+		WHERE from_status_id = ANY($1) ORDER BY from_status_id, to_status_id`
+	createDefaultStatus = `INSERT INTO workflow_statuses (user_id, name, position, is_terminal) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, name) WHERE project_id IS NULL DO NOTHING`
+	createStatus            = `INSERT INTO workflow_statuses (user_id, project_id, name, position, is_terminal) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	updateStatus            = `UPDATE workflow_statuses SET position=$1, is_terminal=$2 WHERE id=$3`
+	deleteStatus            = `DELETE FROM workflow_statuses WHERE id=$1`
+	createTransition        = `INSERT INTO workflow_transitions (from_status_id, to_status_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	deleteTransitions       = `DELETE FROM workflow_transitions WHERE from_status_id = ANY($1) OR to_status_id = ANY($1)`
+	getProjectWorkflowTasks = `SELECT ` + taskColumns + ` FROM tasks WHERE project_id = $1`
+	getProjectOwnerTasks    = `SELECT ` + taskColumns + ` FROM tasks WHERE project_id = $1 AND user_id = $2`
+	getProjectTaskOwners    = `SELECT DISTINCT user_id FROM tasks WHERE project_id = $1`
+	getWorkflowTask         = `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1`
+	getDefaultWorkflowTasks = `SELECT ` + taskColumns + ` FROM tasks
+		WHERE user_id = $1 AND (project_id IS NULL OR NOT EXISTS (SELECT 1 FROM workflow_statuses p WHERE p.project_id = tasks.project_id))`
+	setTaskStatus = `UPDATE tasks SET status_id=$1, is_complete=$2, updated_at=$3,
+		completed_at = CASE WHEN $2 THEN COALESCE(completed_at, $3) ELSE NULL END
+		WHERE id=$4`
+	getProjectOwner = `SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND user_id = $2)`
+)
+
+func queryStatuses(q querier, query string, arg any) ([]models.WorkflowStatus, error) {
+	statuses := []models.WorkflowStatus{}
+	rows, err := q.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status models.WorkflowStatus
+		if err := rows.Scan(&status.ID, &status.Name, &status.Position, &status.IsTerminal); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, rows.Err()
+}
+
+// resolveWorkflow returns the workflow that governs tasks of the given project:
+// the project's own workflow if it has one, otherwise the user's default
+// workflow, which is created on first use.
+func resolveWorkflow(q querier, userId int, projectId *int) (*models.Workflow, error) {
+	workflow := &models.Workflow{}
+	if projectId != nil {
+		statuses, err := queryStatuses(q, getProjectStatuses, *projectId)
+		if err != nil {
+			return nil, err
+		}
+		if len(statuses) > 0 {
+			workflow.ProjectId, workflow.Statuses = projectId, statuses
+		}
+	}
+	if workflow.Statuses == nil {
+		statuses, err := queryStatuses(q, getDefaultStatuses, userId)
+		if err != nil {
+			return nil, err
+		}
+		if len(statuses) == 0 {
+			if statuses, err = createDefaultWorkflow(q, userId); err != nil {
+				return nil, err
+			}
+		}
+		workflow.Statuses = statuses
+	}
+
+	ids := make([]int, len(workflow.Statuses))
+	for i, status := range workflow.Statuses {
+		ids[i] = status.ID
+	}
+	rows, err := q.Query(getTransitions, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workflow.Transitions = []models.Transition{}
+	for rows.Next() {
+		var transition models.Transition
+		if err := rows.Scan(&transition.From, &transition.To); err != nil {
+			return nil, err
+		}
+		workflow.Transitions = append(workflow.Transitions, transition)
+	}
+	return workflow, rows.Err()
+}
+
+func createDefaultWorkflow(q querier, userId int) ([]models.WorkflowStatus, error) {
+	for i, status := range defaultWorkflow {
+		// concurrent requests may race to create the same workflow
+		if _, err := q.Exec(createDefaultStatus, userId, status.Name, i, status.IsTerminal); err != nil {
+			return nil, err
+		}
+	}
+	statuses, err := queryStatuses(q, getDefaultStatuses, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if from.ID == to.ID {
+				continue
+			}
+			if _, err := q.Exec(createTransition, from.ID, to.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return statuses, nil
+}
+
+func findStatus(workflow *models.Workflow, statusId int) *models.WorkflowStatus {
+	for i := range workflow.Statuses {
+		if workflow.Statuses[i].ID == statusId {
+			return &workflow.Statuses[i]
+		}
+	}
+	return nil
+}
+
+func findStatusByName(workflow *models.Workflow, name string) *models.WorkflowStatus {
+	for i := range workflow.Statuses {
+		if workflow.Statuses[i].Name == name {
+			return &workflow.Statuses[i]
+		}
+	}
+	return nil
+}
+
+func allowsTransition(workflow *models.Workflow, from, to int) bool {
+	for _, transition := range workflow.Transitions {
+		if transition.From == from && transition.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// firstStatus returns the first status by position with the given terminal
+// flag that can be reached from the current status (any, if from is nil).
+func firstStatus(workflow *models.Workflow, from *models.WorkflowStatus, terminal bool) *models.WorkflowStatus {
+	for i := range workflow.Statuses {
+		status := &workflow.Statuses[i]
+		if status.IsTerminal != terminal {
+			continue
+		}
+		if from == nil || allowsTransition(workflow, from.ID, status.ID) {
+			return status
+		}
+	}
+	return nil
+}
+
+// nextTaskStatus validates a status change of a task. Clients unaware of
+// workflows only send is_complete; for them the task moves to the first
+// terminal (or non-terminal) status allowed from the current one.
+func nextTaskStatus(workflow *models.Workflow, current models.Task, statusId *int, isComplete bool) (*models.WorkflowStatus, error) {
+	var from *models.WorkflowStatus
+	if current.Status != nil {
+		from = findStatus(workflow, current.Status.ID)
+	}
+	if statusId != nil {
+		to := findStatus(workflow, *statusId)
+		if to == nil {
+			return nil, fmt.Errorf("%w: status %d is not part of the task's workflow", ErrInvalidInput, *statusId)
+		}
+		if from != nil && from.ID != to.ID && !allowsTransition(workflow, from.ID, to.ID) {
+			return nil, fmt.Errorf("%w: moving from %q to %q is not allowed", ErrConflict, from.Name, to.Name)
+		}
+		return to, nil
+	}
+	if from != nil && from.IsTerminal == isComplete {
+		return from, nil
+	}
+	to := firstStatus(workflow, from, isComplete)
+	if to == nil {
+		return nil, fmt.Errorf("%w: the workflow does not allow this change of is_complete", ErrConflict)
+	}
+	return to, nil
+}
+
+// initialStatus is the status new tasks start in.
+func initialStatus(workflow *models.Workflow, statusId *int) (*models.WorkflowStatus, error) {
+	if statusId == nil {
+		return firstStatus(workflow, nil, false), nil
+	}
+	status := findStatus(workflow, *statusId)
+	if status == nil {
+		return nil, fmt.Errorf("%w: status %d is not part of the task's workflow", ErrInvalidInput, *statusId)
+	}
+	if status.IsTerminal {
+		return nil, fmt.Errorf("%w: new tasks cannot start in terminal status %q", ErrInvalidInput, status.Name)
+	}
+	return status, nil
+}
+
+// remapStatus picks the status of another workflow matching the old one by
+// name, falling back to the first status with the same completion state.
+func remapStatus(workflow *models.Workflow, name sql.NullString, isComplete bool) *models.WorkflowStatus {
+	if name.Valid {
+		if status := findStatusByName(workflow, name.String); status != nil {
+			return status
+		}
+	}
+	return firstStatus(workflow, nil, isComplete)
+}
+
+func validateWorkflow(workflowDto dtos.Workflow) (dtos.Workflow, error) {
+	if len(workflowDto.Statuses) == 0 {
+		return workflowDto, fmt.Errorf("%w: a workflow needs at least one status", ErrInvalidInput)
+	}
+	seen := make(map[string]bool)
+	terminal, open := false, false
+	for i, status := range workflowDto.Statuses {
+		name := strings.TrimSpace(status.Name)
+		if name == "" || len(name) > maxStatusNameLength {
+			return workflowDto, fmt.Errorf("%w: status names must be 1 to %d characters long", ErrInvalidInput, maxStatusNameLength)
+		}
+		if seen[name] {
+			return workflowDto, fmt.Errorf("%w: duplicate status %q", ErrInvalidInput, name)
+		}
+		seen[name] = true
+		workflowDto.Statuses[i].Name = name
+		terminal, open = terminal || status.IsTerminal, open || !status.IsTerminal
+	}
+	if !terminal || !open {
+		return workflowDto, fmt.Errorf("%w: a workflow needs both terminal and non-terminal statuses", ErrInvalidInput)
+	}
+	for i, transition := range workflowDto.Transitions {
+		from, to := strings.TrimSpace(transition.From), strings.TrimSpace(transition.To)
+		if !seen[from] || !seen[to] {
+			return workflowDto, fmt.Errorf("%w: transition %q -> %q refers to an unknown status", ErrInvalidInput, transition.From, transition.To)
+		}
+		if from == to {
+			return workflowDto, fmt.Errorf("%w: transition %q -> %q does not change the status", ErrInvalidInput, from, to)
+		}
+		workflowDto.Transitions[i] = dtos.WorkflowTransition{From: from, To: to}
+	}
+	return workflowDto, nil
+}
+
+func (s *WorkflowService) checkProject(projectId *int, userId int) error {
+	if projectId == nil {
+		return nil
+	}
+	var ok bool
+	if err := s.db.QueryRow(getProjectOwner, *projectId, userId).Scan(&ok); err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *WorkflowService) Get(userId int, projectId *int) (*models.Workflow, error) {
+	if err := s.checkProject(projectId, userId); err != nil {
+		return nil, err
+	}
+	return resolveWorkflow(s.db, userId, projectId)
+}
+
+// Replace sets the statuses and transitions of the user's default workflow or
+// of a project's own workflow. Statuses are matched with the existing ones by
+// name; tasks in removed statuses move to a status with the same completion
+// state. An empty list of transitions allows every transition.
+func (s *WorkflowService) Replace(userId int, projectId *int, workflowDto dtos.Workflow) error {
+	workflowDto, err := validateWorkflow(workflowDto)
+	if err != nil {
+		return err
+	}
+	if err := s.checkProject(projectId, userId); err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var existing []models.WorkflowStatus
+	if projectId != nil {
+		existing, err = queryStatuses(tx, getProjectStatuses, *projectId)
+	} else {
+		existing, err = queryStatuses(tx, getDefaultStatuses, userId)
+	}
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]models.WorkflowStatus, len(existing))
+	var touched []int
+	for _, status := range existing {
+		byName[status.Name] = status
+		touched = append(touched, status.ID)
+	}
+
+	workflow := &models.Workflow{ProjectId: projectId}
+	for i, statusDto := range workflowDto.Statuses {
+		status, ok := byName[statusDto.Name]
+		if ok {
+			if _, err := tx.Exec(updateStatus, i, statusDto.IsTerminal, status.ID); err != nil {
+				return err
+			}
+			delete(byName, statusDto.Name)
+		} else {
+			if err := tx.QueryRow(createStatus, userId, projectId, statusDto.Name, i, statusDto.IsTerminal).Scan(&status.ID); err != nil {
+				return err
+			}
+			touched = append(touched, status.ID)
+		}
+		status.Name, status.Position, status.IsTerminal = statusDto.Name, i, statusDto.IsTerminal
+		workflow.Statuses = append(workflow.Statuses, status)
+	}
+
+	if _, err := tx.Exec(deleteTransitions, touched); err != nil {
+		return err
+	}
+	if len(workflowDto.Transitions) == 0 {
+		for _, from := range workflow.Statuses {
+			for _, to := range workflow.Statuses {
+				if from.ID != to.ID {
+					workflowDto.Transitions = append(workflowDto.Transitions, dtos.WorkflowTransition{From: from.Name, To: to.Name})
+				}
+			}
+		}
+	}
+	for _, transition := range workflowDto.Transitions {
+		from, to := findStatusByName(workflow, transition.From), findStatusByName(workflow, transition.To)
+		if _, err := tx.Exec(createTransition, from.ID, to.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := remapWorkflowTasks(tx, workflow, userId); err != nil {
+		return err
+	}
+	for _, removed := range byName {
+		if _, err := tx.Exec(deleteStatus, removed.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// remapWorkflowTasks moves the tasks governed by the workflow into its
+// statuses and keeps is_complete in line with the terminal flags.
+func remapWorkflowTasks(tx *sql.Tx, workflow *models.Workflow, userId int) error {
+	if workflow.ProjectId != nil {
+		return remapTasks(tx, workflow, userId, getProjectWorkflowTasks, *workflow.ProjectId)
+	}
+	return remapTasks(tx, workflow, userId, getDefaultWorkflowTasks, userId)
+}
+
+// remapTasks moves the tasks selected by the query into statuses of the
+// workflow and records the changes on behalf of the actor. Tasks already in
+// one of its statuses keep it.
+func remapTasks(tx *sql.Tx, workflow *models.Workflow, actorId int, query string, args ...any) error {
+	tasks, err := queryTasks(tx, query, args...)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, task := range tasks {
+		var status *models.WorkflowStatus
+		var statusName sql.NullString
+		if task.Status != nil {
+			status = findStatus(workflow, task.Status.ID)
+			statusName = sql.NullString{String: task.Status.Name, Valid: true}
+		}
+		if status == nil {
+			status = remapStatus(workflow, statusName, task.IsCompleted)
+		}
+		if task.Status != nil && task.Status.ID == status.ID && task.IsCompleted == status.IsTerminal {
+			continue
+		}
+		if _, err := tx.Exec(setTaskStatus, status.ID, status.IsTerminal, now, task.ID); err != nil {
+			return err
+		}
+		remapped := task
+		remapped.Status = &models.TaskStatus{ID: status.ID, Name: status.Name}
+		remapped.IsCompleted = status.IsTerminal
+		if err := recordTaskChange(tx, task.ID, actorId, models.TaskUpdated, &task, &remapped, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services/dtos"
+	"trackerApp/pkg/storage"
+)
+
+type WorkspaceService struct {
+	db    *sql.DB
+	store storage.Store
+}
+
+func NewWorkspaceService(db *sql.DB, store storage.Store) *WorkspaceService {
+	return &WorkspaceService{db: db, store: store}
+}
+
+// workspaceRoleRanks orders the workspace roles; a higher rank includes the
+// rights of the lower ones.
+var workspaceRoleRanks = map[string]int{
+	models.WorkspaceGuest:  1,
+	models.WorkspaceMember: 2,
+	models.WorkspaceAdmin:  3,
+	models.WorkspaceOwner:  4,
+}
+
+const (
+	// inTaskScope selects the tasks a request works on: every task of the
+	// workspace $2, or the personal tasks of the user $1 when no workspace is given.
+	inTaskScope = `(workspace_id = $2 OR ($2::int IS NULL AND workspace_id IS NULL AND user_id = $1))`
+
+	getMemberRole = `SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`
+	lockWorkspace = `SELECT id FROM workspaces WHERE id = $1 FOR UPDATE`
+	getWorkspaces = `SELECT w.id, w.name, m.role, w.create_at FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id WHERE m.user_id = $1 ORDER BY w.name, w.id`
+	createWorkspace      = `INSERT INTO workspaces (name, create_at) VALUES ($1, $2) RETURNING id`
+	updateWorkspaceById  = `UPDATE workspaces SET name=$1 WHERE id=$2`
+	deleteWorkspaceById  = `DELETE FROM workspaces WHERE id=$1`
+	getWorkspaceBlobKeys = `SELECT a.storage_key FROM attachments a JOIN tasks t ON t.id = a.task_id WHERE t.workspace_id = $1`
+	getMembers           = `SELECT m.user_id, u.username, m.role, m.create_at FROM workspace_members m
+		JOIN users u ON u.id = m.user_id WHERE m.workspace_id = $1 ORDER BY u.username`
+	createMember = `INSERT INTO workspace_members (workspace_id, user_id, role, create_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace_id, user_id) DO NOTHING`
+	setMemberRole    = `UPDATE workspace_members SET role=$1 WHERE workspace_id=$2 AND user_id=$3`
+	deleteMember     = `DELETE FROM workspace_members WHERE workspace_id=$1 AND user_id=$2`
+	countOwners      = `SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1 AND role = 'owner'`
+	createInvitation = `INSERT INTO workspace_invitations (workspace_id, user_id, role, invited_by, create_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	getInvitations = `SELECT i.id, i.workspace_id, w.name, u.username, b.username, i.role, i.status, i.create_at
+		FROM workspace_invitations i JOIN workspaces w ON w.id = i.workspace_id JOIN users u ON u.id = i.user_id
+		LEFT JOIN users b ON b.id = i.invited_by
+		WHERE i.user_id = $1 AND i.status = 'pending' ORDER BY i.create_at, i.id`
+	getPendingInvitation = `SELECT workspace_id, role FROM workspace_invitations
+		WHERE id = $1 AND user_id = $2 AND status = 'pending' FOR UPDATE`
+	respondInvitation = `UPDATE workspace_invitations SET status=$1, responded_at=$2 WHERE id=$3 AND user_id=$4 AND status = 'pending'`
+)
+
+// checkWorkspaceRole verifies that a user belongs to a workspace with at
+// least the given role and returns the user's role. Workspaces the user is
+// not a member of are reported as not found.
+func checkWorkspaceRole(q querier, workspaceId, userId int, min string) (string, error) {
+	var role string
+	err := q.QueryRow(getMemberRole, workspaceId, userId).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if workspaceRoleRanks[role] < workspaceRoleRanks[min] {
+		return "", fmt.Errorf("%w: %s role in workspace %d is required", ErrForbidden, min, workspaceId)
+	}
+	return role, nil
+}
+
+// checkTaskScope verifies that a user may read the tasks of a workspace.
+// Without a workspace the user works on their personal tasks.
+func checkTaskScope(q querier, userId int, workspaceId *int) error {
+	if workspaceId == nil {
+		return nil
+	}
+	_, err := checkWorkspaceRole(q, *workspaceId, userId, models.WorkspaceGuest)
+	return err
+}
+
+func validateWorkspaceRole(role string) error {
+	if _, ok := workspaceRoleRanks[role]; !ok {
+		return fmt.Errorf("%w: role must be one of owner, admin, member or guest", ErrInvalidInput)
+	}
+	return nil
+}
+
+func validateWorkspaceName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%w: workspace name is required", ErrInvalidInput)
+	}
+	if len(name) > 255 {
+		return "", fmt.Errorf("%w: workspace name is longer than 255 characters", ErrInvalidInput)
+	}
+	return name, nil
+}
+
+// checkOwnerRemains refuses changes that would leave a workspace without an
+// owner. The workspace row must be locked.
+func checkOwnerRemains(tx *sql.Tx, workspaceId int) error {
+	var owners int
+	if err := tx.QueryRow(countOwners, workspaceId).Scan(&owners); err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return fmt.Errorf("%w: workspace %d needs at least one owner", ErrConflict, workspaceId)
+	}
+	return nil
+}
+
+// Role returns the role of a user in a workspace.
+func (s *WorkspaceService) Role(workspaceId, userId int) (string, error) {
+	return checkWorkspaceRole(s.db, workspaceId, userId, models.WorkspaceGuest)
+}
+
+func (s *WorkspaceService) Get(userId int) ([]models.Workspace, error) {
+	workspaces := []models.Workspace{}
+	rows, err := s.db.Query(getWorkspaces, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var workspace models.Workspace
+		if err := rows.Scan(&workspace.ID, &workspace.Name, &workspace.Role, &workspace.CreateAt); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, workspace)
+	}
+	return workspaces, rows.Err()
+}
+
+// Create adds a workspace with the user as its owner.
+func (s *WorkspaceService) Create(userId int, form dtos.WorkspaceForm) (int, error) {
+	name, err := validateWorkspaceName(form.Name)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var id int
+	if err := tx.QueryRow(createWorkspace, name, now).Scan(&id); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(createMember, id, userId, models.WorkspaceOwner, now); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (s *WorkspaceService) Update(workspaceId, userId int, form dtos.WorkspaceForm) error {
+	name, err := validateWorkspaceName(form.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := checkWorkspaceRole(s.db, workspaceId, userId, models.WorkspaceAdmin); err != nil {
+		return err
+	}
+	res, err := s.db.Exec(updateWorkspaceById, name, workspaceId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// Delete removes a workspace with all its tasks, then the contents of their
+// attachments. Only owners may delete a workspace.
+func (s *WorkspaceService) Delete(workspaceId, userId int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := checkWorkspaceRole(tx, workspaceId, userId, models.WorkspaceOwner); err != nil {
+		return err
+	}
+	keys, err := getBlobKeys(tx, getWorkspaceBlobKeys, workspaceId)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(deleteWorkspaceById, workspaceId); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	deleteBlobs(s.store, keys)
+	return nil
+}
+
+func (s *WorkspaceService) GetMembers(workspaceId, userId int) ([]models.Member, error) {
+	if _, err := checkWorkspaceRole(s.db, workspaceId, userId, models.WorkspaceGuest); err != nil {
+		return nil, err
+	}
+	members := []models.Member{}
+	rows, err := s.db.Query(getMembers, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member models.Member
+		if err := rows.Scan(&member.UserId, &member.Username, &member.Role, &member.CreateAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// SetMemberRole changes the role of a member. Admins and owners cannot grant
+// more than their own role nor change members above them.
+func (s *WorkspaceService) SetMemberRole(workspaceId, memberId, userId int, role string) error {
+	if err := validateWorkspaceRole(role); err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	actorRole, err := checkWorkspaceRole(tx, workspaceId, userId, models.WorkspaceAdmin)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(lockWorkspace, workspaceId); err != nil {
+		return err
+	}
+	var current string
+	err = tx.QueryRow(getMemberRole, workspaceId, memberId).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if workspaceRoleRanks[current] > workspaceRoleRanks[actorRole] || workspaceRoleRanks[role] > workspaceRoleRanks[actorRole] {
+		return fmt.Errorf("%w: cannot grant or change roles above %s", ErrForbidden, actorRole)
+	}
+	if current == models.WorkspaceOwner && role != models.WorkspaceOwner {
+		if err := checkOwnerRemains(tx, workspaceId); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(setMemberRole, role, workspaceId, memberId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveMember takes a user out of a workspace. Members may leave on their
+// own; admins and owners remove members up to their own role. The tasks the
+// member created stay in the workspace.
+func (s *WorkspaceService) RemoveMember(workspaceId, memberId, userId int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	minRole := models.WorkspaceAdmin
+	if memberId == userId {
+		minRole = models.WorkspaceGuest
+	}
+	actorRole, err := checkWorkspaceRole(tx, workspaceId, userId, minRole)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(lockWorkspace, workspaceId); err != nil {
+		return err
+	}
+	var current string
+	err = tx.QueryRow(getMemberRole, workspaceId, memberId).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if workspaceRoleRanks[current] > workspaceRoleRanks[actorRole] {
+		return fmt.Errorf("%w: cannot remove members above %s", ErrForbidden, actorRole)
+	}
+	if current == models.WorkspaceOwner {
+		if err := checkOwnerRemains(tx, workspaceId); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(deleteMember, workspaceId, memberId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Invite asks a user to join a workspace. The role cannot exceed the
+// inviter's own.
+func (s *WorkspaceService) Invite(workspaceId, userId int, form dtos.InvitationForm) (int, error) {
+	if err := validateWorkspaceRole(form.Role); err != nil {
+		return 0, err
+	}
+	actorRole, err := checkWorkspaceRole(s.db, workspaceId, userId, models.WorkspaceAdmin)
+	if err != nil {
+		return 0, err
+	}
+	if workspaceRoleRanks[form.Role] > workspaceRoleRanks[actorRole] {
+		return 0, fmt.Errorf("%w: cannot invite with a role above %s", ErrForbidden, actorRole)
+	}
+	var inviteeId int
+	err = s.db.QueryRow(getUserIdByName, form.Username).Scan(&inviteeId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: user %q does not exist", ErrInvalidInput, form.Username)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if _, err := checkWorkspaceRole(s.db, workspaceId, inviteeId, models.WorkspaceGuest); err == nil {
+		return 0, fmt.Errorf("%w: %s is already a member", ErrConflict, form.Username)
+	} else if !errors.Is(err, ErrNotFound) {
+		return 0, err
+	}
+	var id int
+	err = s.db.QueryRow(createInvitation, workspaceId, inviteeId, form.Role, userId, time.Now()).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, fmt.Errorf("%w: %s is already invited", ErrConflict, form.Username)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetInvitations lists the pending invitations of a user.
+func (s *WorkspaceService) GetInvitations(userId int) ([]models.Invitation, error) {
+	invitations := []models.Invitation{}
+	rows, err := s.db.Query(getInvitations, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i models.Invitation
+		if err := rows.Scan(&i.ID, &i.WorkspaceId, &i.Workspace, &i.Username, &i.InvitedBy, &i.Role, &i.Status, &i.CreateAt); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, i)
+	}
+	return invitations, rows.Err()
+}
+
+// AcceptInvitation makes the user a member with the invited role.
+func (s *WorkspaceService) AcceptInvitation(invitationId, userId int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var workspaceId int
+	var role string
+	err = tx.QueryRow(getPendingInvitation, invitationId, userId).Scan(&workspaceId, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if _, err := tx.Exec(createMember, workspaceId, userId, role, now); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(respondInvitation, models.InvitationAccepted, now, invitationId, userId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *WorkspaceService) DeclineInvitation(invitationId, userId int) error {
+	res, err := s.db.Exec(respondInvitation, models.InvitationDeclined, time.Now(), invitationId, userId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
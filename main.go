@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -12,7 +13,10 @@ import (
 	"trackerApp/internal/handlers"
 	"trackerApp/internal/services"
 	"trackerApp/pkg/httpServer"
+	"trackerApp/pkg/oidc"
 	"trackerApp/pkg/postgres"
+	"trackerApp/pkg/signing"
+	"trackerApp/pkg/storage"
 
 	"github.com/spf13/viper"
 )
@@ -47,7 +51,30 @@ func main() {
 	}
 	defer db.Close()
 
-	service := services.NewService(db)
+	blobStore, err := newBlobStore()
+	if err != nil {
+		panic(err)
+	}
+	signingKeys, err := newSigningKeys()
+	if err != nil {
+		panic(err)
+	}
+	oidcProvider, err := newOIDCProvider()
+	if err != nil {
+		panic(err)
+	}
+	service := services.NewService(db, services.Config{
+		BlobStore:         blobStore,
+		MaxAttachmentSize: viper.GetInt64("attachments.maxSize"),
+		AttachmentTypes:   viper.GetStringSlice("attachments.allowedTypes"),
+		SigningKeys:       signingKeys,
+		AccessTokenTTL:    viper.GetDuration("auth.accessTokenTTL"),
+		RefreshTokenTTL:   viper.GetDuration("auth.refreshTokenTTL"),
+		OIDC:              oidcProvider,
+		OIDCAutoProvision: viper.GetBool("oidc.autoProvision"),
+	})
+	stopPurge := startTrashPurge(service, viper.GetDuration("trash.retention"), viper.GetDuration("trash.purgeInterval"))
+	defer stopPurge()
 	handler := handlers.NewHandler(service)
 
 	server := new(httpServer.Server)
@@ -72,3 +99,87 @@ func main() {
 	}
 	log.Println("Server exiting")
 }
+
+// newBlobStore creates the attachment store selected by attachments.storage.
+func newBlobStore() (storage.Store, error) {
+	switch kind := viper.GetString("attachments.storage"); kind {
+	case "", "local":
+		return storage.NewLocalStore(viper.GetString("attachments.dir"))
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  viper.GetString("attachments.s3.endpoint"),
+			Region:    viper.GetString("attachments.s3.region"),
+			Bucket:    viper.GetString("attachments.s3.bucket"),
+			AccessKey: os.ExpandEnv(viper.GetString("attachments.s3.accessKey")),
+			SecretKey: os.ExpandEnv(viper.GetString("attachments.s3.secretKey")),
+			PathStyle: viper.GetBool("attachments.s3.pathStyle"),
+		}, nil)
+	default:
+		return nil, fmt.Errorf("unknown attachments storage %q", kind)
+	}
+}
+
+// newSigningKeys loads the JWT keys listed in auth.keys.
+func newSigningKeys() (*signing.KeySet, error) {
+	var configs []signing.KeyConfig
+	if err := viper.UnmarshalKey("auth.keys", &configs); err != nil {
+		return nil, err
+	}
+	keys := make([]*signing.Key, len(configs))
+	for i, cfg := range configs {
+		key, err := signing.LoadKey(cfg)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	return signing.NewKeySet(viper.GetString("auth.signingKey"), keys...)
+}
+
+// newOIDCProvider discovers the identity provider configured under oidc,
+// or returns nil if there is none.
+func newOIDCProvider() (*oidc.Provider, error) {
+	issuer := viper.GetString("oidc.issuer")
+	if issuer == "" {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return oidc.Discover(ctx, oidc.Config{
+		Issuer:       issuer,
+		ClientID:     viper.GetString("oidc.clientId"),
+		ClientSecret: os.ExpandEnv(viper.GetString("oidc.clientSecret")),
+		RedirectURL:  viper.GetString("oidc.redirectUrl"),
+		Scopes:       viper.GetStringSlice("oidc.scopes"),
+	}, nil)
+}
+
+// startTrashPurge purges the tasks that have been in the trash longer than
+// retention every interval until the returned function is called.
+func startTrashPurge(service *services.Service, retention, interval time.Duration) func() {
+	if retention <= 0 || interval <= 0 {
+		log.Println("trash: purging is disabled")
+		return func() {}
+	}
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			n, err := service.ITaskService.PurgeTrash(time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("trash: purge: %v", err)
+			} else if n > 0 {
+				log.Printf("trash: purged %d tasks", n)
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS tasks_user_id_due_at_idx;
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS due_at,
+    DROP COLUMN IF EXISTS start_at;
@@ -0,0 +1,4 @@
+ALTER TABLE tasks
+    ADD COLUMN start_at TIMESTAMPTZ,
+    ADD COLUMN due_at TIMESTAMPTZ;
+CREATE INDEX tasks_user_id_due_at_idx ON tasks (user_id, due_at) WHERE due_at IS NOT NULL;
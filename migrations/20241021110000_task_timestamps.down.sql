@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS tasks_user_id_create_at_idx;
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS updated_at,
+    ALTER COLUMN create_at DROP DEFAULT,
+    ALTER COLUMN create_at TYPE VARCHAR(255) USING to_char(create_at, 'DD/MM/YYYY');
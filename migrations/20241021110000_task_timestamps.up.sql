@@ -0,0 +1,12 @@
+ALTER TABLE tasks
+    ALTER COLUMN create_at TYPE TIMESTAMPTZ
+        USING CASE
+            WHEN create_at ~ '^\d{2}/\d{2}/\d{4}$' THEN to_timestamp(create_at, 'DD/MM/YYYY')
+            ELSE create_at::TIMESTAMPTZ
+        END,
+    ALTER COLUMN create_at SET DEFAULT now(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN completed_at TIMESTAMPTZ;
+UPDATE tasks SET updated_at = create_at;
+UPDATE tasks SET completed_at = create_at WHERE is_complete;
+CREATE INDEX tasks_user_id_create_at_idx ON tasks (user_id, create_at);
@@ -0,0 +1,13 @@
+CREATE TABLE labels(
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    color VARCHAR(16) NOT NULL DEFAULT '',
+    UNIQUE (user_id, name)
+);
+CREATE TABLE task_labels(
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    label_id INT NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+CREATE INDEX task_labels_label_id_idx ON task_labels (label_id);
@@ -0,0 +1,12 @@
+CREATE TABLE projects(
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    color VARCHAR(16) NOT NULL DEFAULT '',
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    create_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX projects_user_id_idx ON projects (user_id);
+ALTER TABLE tasks ADD COLUMN project_id INT REFERENCES projects(id) ON DELETE SET NULL;
+CREATE INDEX tasks_project_id_idx ON tasks (project_id);
@@ -0,0 +1,2 @@
+ALTER TABLE tasks ADD COLUMN parent_task_id INT REFERENCES tasks(id) ON DELETE CASCADE;
+CREATE INDEX tasks_parent_task_id_idx ON tasks (parent_task_id);
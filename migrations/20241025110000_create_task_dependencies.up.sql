@@ -0,0 +1,7 @@
+CREATE TABLE task_dependencies(
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    blocker_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, blocker_id),
+    CHECK (task_id <> blocker_id)
+);
+CREATE INDEX task_dependencies_blocker_id_idx ON task_dependencies (blocker_id);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+    ADD COLUMN recurrence TEXT,
+    ADD COLUMN recurrence_tz TEXT;
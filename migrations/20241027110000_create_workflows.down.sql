@@ -0,0 +1,5 @@
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS priority,
+    DROP COLUMN IF EXISTS status_id;
+DROP TABLE IF EXISTS workflow_transitions;
+DROP TABLE IF EXISTS workflow_statuses;
@@ -0,0 +1,19 @@
+CREATE TABLE workflow_statuses(
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id INT REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    position INT NOT NULL,
+    is_terminal BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE UNIQUE INDEX workflow_statuses_user_name_idx ON workflow_statuses (user_id, name) WHERE project_id IS NULL;
+CREATE UNIQUE INDEX workflow_statuses_project_name_idx ON workflow_statuses (project_id, name) WHERE project_id IS NOT NULL;
+CREATE TABLE workflow_transitions(
+    from_status_id INT NOT NULL REFERENCES workflow_statuses(id) ON DELETE CASCADE,
+    to_status_id INT NOT NULL REFERENCES workflow_statuses(id) ON DELETE CASCADE,
+    PRIMARY KEY (from_status_id, to_status_id)
+);
+ALTER TABLE tasks
+    ADD COLUMN status_id INT REFERENCES workflow_statuses(id) ON DELETE SET NULL,
+    ADD COLUMN priority SMALLINT NOT NULL DEFAULT 1;
+CREATE INDEX tasks_status_id_idx ON tasks (status_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tasks_user_rank_idx;
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS rank,
+    DROP COLUMN IF EXISTS column_id;
+DROP TABLE IF EXISTS board_columns;
@@ -0,0 +1,19 @@
+CREATE TABLE board_columns(
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    position INT NOT NULL,
+    is_done BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (user_id, name)
+);
+-- ranks are compared bytewise, whatever the database collation is
+ALTER TABLE tasks
+    ADD COLUMN column_id INT REFERENCES board_columns(id) ON DELETE SET NULL,
+    ADD COLUMN rank TEXT COLLATE "C" NOT NULL DEFAULT '';
+UPDATE tasks SET rank = ranked.rank
+FROM (
+    SELECT id, rtrim(lpad((row_number() OVER (PARTITION BY user_id ORDER BY create_at, id))::text, 10, '0'), '0') AS rank
+    FROM tasks
+) ranked
+WHERE tasks.id = ranked.id;
+CREATE INDEX tasks_user_rank_idx ON tasks (user_id, rank);
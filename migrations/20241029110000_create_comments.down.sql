@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS comment_versions;
+DROP TABLE IF EXISTS comments;
@@ -0,0 +1,16 @@
+CREATE TABLE comments(
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    edited_at TIMESTAMPTZ
+);
+CREATE INDEX comments_task_id_idx ON comments (task_id, create_at);
+CREATE TABLE comment_versions(
+    id SERIAL PRIMARY KEY,
+    comment_id INT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    create_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX comment_versions_comment_id_idx ON comment_versions (comment_id);
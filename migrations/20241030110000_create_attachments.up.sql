@@ -0,0 +1,11 @@
+CREATE TABLE attachments(
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    create_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX attachments_task_id_idx ON attachments (task_id);
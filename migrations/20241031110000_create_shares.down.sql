@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS project_shares;
+DROP TABLE IF EXISTS task_shares;
@@ -0,0 +1,27 @@
+CREATE TABLE workspaces(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    create_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE workspace_members(
+    workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'guest')),
+    create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+CREATE INDEX workspace_members_user_id_idx ON workspace_members (user_id);
+CREATE TABLE workspace_invitations(
+    id SERIAL PRIMARY KEY,
+    workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'guest')),
+    invited_by INT REFERENCES users(id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+    create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    responded_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX workspace_invitations_pending_idx ON workspace_invitations (workspace_id, user_id) WHERE status = 'pending';
+CREATE INDEX workspace_invitations_user_id_idx ON workspace_invitations (user_id);
+ALTER TABLE tasks ADD COLUMN workspace_id INT REFERENCES workspaces(id) ON DELETE CASCADE;
+CREATE INDEX tasks_workspace_id_idx ON tasks (workspace_id);
@@ -0,0 +1,17 @@
+CREATE TABLE task_assignees(
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_by INT REFERENCES users(id) ON DELETE SET NULL,
+    assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (task_id, user_id)
+);
+CREATE INDEX task_assignees_user_id_idx ON task_assignees (user_id);
+CREATE TABLE task_assignment_changes(
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action TEXT NOT NULL CHECK (action IN ('assigned', 'unassigned')),
+    changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    create_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX task_assignment_changes_task_id_idx ON task_assignment_changes (task_id);
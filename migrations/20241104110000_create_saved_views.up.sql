@@ -0,0 +1,13 @@
+CREATE TABLE saved_views(
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    sort TEXT NOT NULL DEFAULT 'created',
+    sort_order TEXT NOT NULL DEFAULT 'asc' CHECK (sort_order IN ('asc', 'desc')),
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_id, name)
+);
+CREATE UNIQUE INDEX saved_views_default_idx ON saved_views (user_id) WHERE is_default;
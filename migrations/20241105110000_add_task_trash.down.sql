@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_deleted_at_idx;
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,2 @@
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMPTZ;
+CREATE INDEX tasks_deleted_at_idx ON tasks (deleted_at) WHERE deleted_at IS NOT NULL;
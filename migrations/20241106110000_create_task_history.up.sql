@@ -0,0 +1,11 @@
+-- task_id has no foreign key so that the history outlives purged tasks
+CREATE TABLE task_history(
+    id BIGSERIAL PRIMARY KEY,
+    task_id INT NOT NULL,
+    actor_id INT REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL CHECK (action IN ('created', 'updated', 'deleted', 'restored', 'purged')),
+    changes JSONB NOT NULL DEFAULT '{}',
+    create_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX task_history_task_id_idx ON task_history (task_id, id);
+CREATE INDEX task_history_actor_id_idx ON task_history (actor_id, id);
@@ -0,0 +1,2 @@
+-- set while a signed-in user links an identity instead of signing in
+ALTER TABLE oidc_logins ADD COLUMN user_id INT REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,127 @@
+// Package rank generates lexicographic sort keys for manually ordered lists.
+// A key can always be generated between two existing keys, so moving an item
+// only rewrites the key of the moved item.
+//
+// Keys consist of the digits 0-9a-z and never end in '0', which guarantees
+// that there is room below every key. They must be compared bytewise (the
+// "C" collation in PostgreSQL).
+package rank
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// Valid reports whether key is a well-formed rank key.
+func Valid(key string) bool {
+	if key == "" || key[len(key)-1] == digits[0] {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Between returns a key sorting strictly between a and b. An empty a stands
+// for the start of the list and an empty b for its end.
+func Between(a, b string) (string, error) {
+	if a != "" && !Valid(a) {
+		return "", fmt.Errorf("rank: invalid key %q", a)
+	}
+	if b != "" && !Valid(b) {
+		return "", fmt.Errorf("rank: invalid key %q", b)
+	}
+	if a != "" && b != "" && a >= b {
+		return "", errors.New("rank: keys are out of order")
+	}
+	return midpoint(a, b), nil
+}
+
+func midpoint(a, b string) string {
+	if b != "" {
+		// a shorter key behaves as if padded with zeros
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(suffix(a, n), b[n:])
+		}
+	}
+	lo, hi := 0, base
+	if a != "" {
+		lo = strings.IndexByte(digits, a[0])
+	}
+	if b != "" {
+		hi = strings.IndexByte(digits, b[0])
+	}
+	if hi-lo > 1 {
+		return string(digits[(lo+hi)/2])
+	}
+	// the first digits are adjacent
+	if len(b) > 1 {
+		return b[:1]
+	}
+	return string(digits[lo]) + midpoint(suffix(a, 1), "")
+}
+
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return digits[0]
+}
+
+func suffix(key string, i int) string {
+	if i < len(key) {
+		return key[i:]
+	}
+	return ""
+}
+
+// After returns a short key sorting after a, for appending to a list whose
+// last key is a. An empty a yields the key of the first item.
+func After(a string) string {
+	if a == "" {
+		return string(digits[base/2])
+	}
+	// count up like a number, except that wrapped digits restart at '1' so
+	// that the key never ends in '0'
+	for i := len(a) - 1; i >= 0; i-- {
+		if d := strings.IndexByte(digits, a[i]); d >= 0 && d < base-1 {
+			return a[:i] + string(digits[d+1]) + strings.Repeat(digits[1:2], len(a)-i-1)
+		}
+	}
+	// every digit is the largest one; doubling the length keeps keys
+	// logarithmic in the number of appends
+	return a + strings.Repeat(digits[:1], len(a)-1) + digits[1:2]
+}
+
+// Spread returns n evenly spaced keys in ascending order, used to renumber a
+// list whose keys have run out of space or collided.
+func Spread(n int) []string {
+	width, capacity := 1, base
+	for capacity <= n {
+		width, capacity = width+1, capacity*base
+	}
+	step := capacity / (n + 1)
+	keys := make([]string, n)
+	for i := range keys {
+		value := (i + 1) * step
+		key := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			key[j] = digits[value%base]
+			value /= base
+		}
+		keys[i] = strings.TrimRight(string(key), digits[:1])
+	}
+	return keys
+}
@@ -0,0 +1,368 @@
+// Package rrule implements the subset of iCalendar (RFC 5545) recurrence rules
+// used by the tracker: FREQ, INTERVAL, BYDAY, UNTIL and COUNT.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency int
+
+const (
+	Daily Frequency = iota
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencyNames = map[string]Frequency{
+	"DAILY":   Daily,
+	"WEEKLY":  Weekly,
+	"MONTHLY": Monthly,
+	"YEARLY":  Yearly,
+}
+
+func (f Frequency) String() string {
+	switch f {
+	case Daily:
+		return "DAILY"
+	case Weekly:
+		return "WEEKLY"
+	case Monthly:
+		return "MONTHLY"
+	case Yearly:
+		return "YEARLY"
+	}
+	return "UNKNOWN"
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Weekday is a BYDAY entry. N is the optional ordinal, e.g. -1 in "-1FR" (the
+// last Friday of the month); zero means every such weekday of the period.
+type Weekday struct {
+	Day time.Weekday
+	N   int
+}
+
+func (w Weekday) String() string {
+	name := strings.ToUpper(w.Day.String()[:2])
+	if w.N != 0 {
+		return strconv.Itoa(w.N) + name
+	}
+	return name
+}
+
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []Weekday
+	Until    *time.Time
+	Count    int
+}
+
+// maxEmptyPeriods bounds the search for the next occurrence so that rules
+// which match very rarely (or never) cannot loop forever.
+const maxEmptyPeriods = 1000
+
+const (
+	untilDateTimeUTC = "20060102T150405Z"
+	untilDateTime    = "20060102T150405"
+	untilDate        = "20060102"
+)
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10".
+// An optional "RRULE:" prefix is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("rrule: empty rule")
+	}
+	rule := &Rule{Interval: 1}
+	hasFreq := false
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("rrule: malformed part %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			freq, ok := frequencyNames[strings.ToUpper(value)]
+			if !ok {
+				return nil, fmt.Errorf("rrule: unsupported FREQ %q", value)
+			}
+			rule.Freq, hasFreq = freq, true
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("rrule: INTERVAL must be a positive integer")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("rrule: COUNT must be a positive integer")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, item := range strings.Split(value, ",") {
+				day, err := parseWeekday(item)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "WKST":
+			// weeks always start on Monday, which is the RFC 5545 default
+			if strings.ToUpper(value) != "MO" {
+				return nil, fmt.Errorf("rrule: only WKST=MO is supported")
+			}
+		default:
+			return nil, fmt.Errorf("rrule: unsupported part %q", key)
+		}
+	}
+	if !hasFreq {
+		return nil, errors.New("rrule: FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("rrule: COUNT and UNTIL are mutually exclusive")
+	}
+	for _, day := range rule.ByDay {
+		if day.N != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return nil, fmt.Errorf("rrule: ordinal BYDAY %s requires MONTHLY or YEARLY", day)
+		}
+	}
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{untilDateTimeUTC, untilDateTime, untilDate} {
+		if until, err := time.Parse(layout, value); err == nil {
+			if layout == untilDate {
+				// a date only UNTIL includes the whole day
+				until = until.Add(24*time.Hour - time.Nanosecond)
+			}
+			return until, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("rrule: malformed UNTIL %q", value)
+}
+
+func parseWeekday(s string) (Weekday, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return Weekday{}, fmt.Errorf("rrule: malformed BYDAY %q", s)
+	}
+	day, ok := weekdayNames[s[len(s)-2:]]
+	if !ok {
+		return Weekday{}, fmt.Errorf("rrule: malformed BYDAY %q", s)
+	}
+	w := Weekday{Day: day}
+	if prefix := s[:len(s)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n > 53 || n < -53 {
+			return Weekday{}, fmt.Errorf("rrule: malformed BYDAY %q", s)
+		}
+		w.N = n
+	}
+	return w, nil
+}
+
+// String formats the rule in its canonical RFC 5545 form.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq.String()}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilDateTimeUTC))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Iterate calls fn for every occurrence of the rule starting at dtstart, in
+// chronological order, until fn returns false or the rule is exhausted. As in
+// RFC 5545, dtstart itself is always the first occurrence and counts towards
+// COUNT. Occurrences inherit the time of day and location of dtstart.
+func (r *Rule) Iterate(dtstart time.Time, fn func(time.Time) bool) {
+	if !fn(dtstart) {
+		return
+	}
+	emitted := 1
+	empty := 0
+	for period := 0; ; period++ {
+		found := false
+		for _, occurrence := range r.candidates(dtstart, period) {
+			if !occurrence.After(dtstart) {
+				continue
+			}
+			found = true
+			if r.Count > 0 && emitted >= r.Count {
+				return
+			}
+			if r.Until != nil && occurrence.After(*r.Until) {
+				return
+			}
+			if !fn(occurrence) {
+				return
+			}
+			emitted++
+		}
+		if found {
+			empty = 0
+		} else if empty++; empty > maxEmptyPeriods {
+			return
+		}
+	}
+}
+
+// Next returns the first occurrence after dtstart, or false if the rule has
+// no further occurrences.
+func (r *Rule) Next(dtstart time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	r.Iterate(dtstart, func(t time.Time) bool {
+		if t.Equal(dtstart) {
+			return true
+		}
+		next, found = t, true
+		return false
+	})
+	return next, found
+}
+
+// Take returns up to n occurrences starting with dtstart.
+func (r *Rule) Take(dtstart time.Time, n int) []time.Time {
+	occurrences := make([]time.Time, 0, n)
+	if n <= 0 {
+		return occurrences
+	}
+	r.Iterate(dtstart, func(t time.Time) bool {
+		occurrences = append(occurrences, t)
+		return len(occurrences) < n
+	})
+	return occurrences
+}
+
+// candidates returns the sorted occurrences of the given period, where period
+// zero is the day, week, month or year containing dtstart.
+func (r *Rule) candidates(dtstart time.Time, period int) []time.Time {
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	ns, loc := dtstart.Nanosecond(), dtstart.Location()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hh, mm, ss, ns, loc)
+	}
+	step := period * r.Interval
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := at(y, m, d+step)
+		if r.matchesWeekday(day.Weekday()) {
+			days = append(days, day)
+		}
+	case Weekly:
+		monday := d - (int(dtstart.Weekday())+6)%7 + 7*step
+		if len(r.ByDay) == 0 {
+			days = append(days, at(y, m, d+7*step))
+		}
+		for i := 0; i < 7 && len(r.ByDay) > 0; i++ {
+			day := at(y, m, monday+i)
+			if r.matchesWeekday(day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		first := at(y, m+time.Month(step), 1)
+		if len(r.ByDay) == 0 {
+			if day := at(first.Year(), first.Month(), d); day.Day() == d {
+				days = append(days, day)
+			}
+			break
+		}
+		last := at(first.Year(), first.Month()+1, 0)
+		days = r.byDayWithin(first, last)
+	case Yearly:
+		year := y + step
+		if len(r.ByDay) == 0 {
+			if day := at(year, m, d); day.Day() == d {
+				days = append(days, day)
+			}
+			break
+		}
+		days = r.byDayWithin(at(year, time.January, 1), at(year, time.December, 31))
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+func (r *Rule) matchesWeekday(day time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, w := range r.ByDay {
+		if w.Day == day {
+			return true
+		}
+	}
+	return false
+}
+
+// byDayWithin expands BYDAY inside the inclusive range [first, last].
+func (r *Rule) byDayWithin(first, last time.Time) []time.Time {
+	var matches []time.Time
+	seen := make(map[int]bool)
+	for _, w := range r.ByDay {
+		var all []time.Time
+		for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == w.Day {
+				all = append(all, day)
+			}
+		}
+		switch {
+		case w.N > 0 && w.N <= len(all):
+			all = all[w.N-1 : w.N]
+		case w.N < 0 && -w.N <= len(all):
+			all = all[len(all)+w.N : len(all)+w.N+1]
+		case w.N != 0:
+			all = nil
+		}
+		for _, day := range all {
+			if !seen[day.YearDay()] {
+				seen[day.YearDay()] = true
+				matches = append(matches, day)
+			}
+		}
+	}
+	return matches
+}
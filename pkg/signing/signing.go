@@ -0,0 +1,244 @@
+// Package signing holds the keys JWTs are signed and verified with. Every
+// key has an ID that issued tokens carry in their "kid" header, so that
+// several keys can verify tokens at once while a new one is rolled out and
+// an old one retired.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MinSecretLength is the shortest HS256 secret accepted, in bytes.
+const MinSecretLength = 32
+
+// Key is a named key. Keys without a private part only verify tokens.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	sign   any
+	verify any
+}
+
+// CanSign reports whether tokens can be signed with the key.
+func (k *Key) CanSign() bool {
+	return k.sign != nil
+}
+
+// NewHMACKey creates an HS256 key from a shared secret.
+func NewHMACKey(id string, secret []byte) (*Key, error) {
+	if len(secret) < MinSecretLength {
+		return nil, fmt.Errorf("signing: key %q: secret is shorter than %d bytes", id, MinSecretLength)
+	}
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, sign: secret, verify: secret}, nil
+}
+
+// GenerateHMACKey creates an HS256 key with a random secret.
+func GenerateHMACKey(id string) (*Key, error) {
+	secret := make([]byte, MinSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return NewHMACKey(id, secret)
+}
+
+// ParsePrivateKey reads a PEM encoded private key for RS256 or EdDSA.
+func ParsePrivateKey(id, algorithm string, data []byte) (*Key, error) {
+	switch algorithm {
+	case "RS256":
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("signing: key %q: %w", id, err)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, sign: key, verify: &key.PublicKey}, nil
+	case "EdDSA":
+		key, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("signing: key %q: %w", id, err)
+		}
+		private, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing: key %q is not an Ed25519 key", id)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, sign: private, verify: private.Public()}, nil
+	}
+	return nil, fmt.Errorf("signing: key %q: unsupported algorithm %q", id, algorithm)
+}
+
+// ParsePublicKey reads a PEM encoded public key for RS256 or EdDSA. The
+// key only verifies tokens.
+func ParsePublicKey(id, algorithm string, data []byte) (*Key, error) {
+	switch algorithm {
+	case "RS256":
+		key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("signing: key %q: %w", id, err)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, verify: key}, nil
+	case "EdDSA":
+		key, err := jwt.ParseEdPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("signing: key %q: %w", id, err)
+		}
+		public, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("signing: key %q is not an Ed25519 key", id)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, verify: public}, nil
+	}
+	return nil, fmt.Errorf("signing: key %q: unsupported algorithm %q", id, algorithm)
+}
+
+// KeyConfig describes a key in the configuration. HS256 keys take a
+// secret, in which ${VAR} references are replaced from the environment;
+// RS256 and EdDSA keys take a PEM file with the private key, or with only
+// the public key for keys that no longer sign.
+type KeyConfig struct {
+	ID             string
+	Algorithm      string
+	Secret         string
+	PrivateKeyFile string
+	PublicKeyFile  string
+}
+
+// LoadKey creates the key a KeyConfig describes.
+func LoadKey(cfg KeyConfig) (*Key, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("signing: key without id")
+	}
+	switch cfg.Algorithm {
+	case "", "HS256":
+		return NewHMACKey(cfg.ID, []byte(os.ExpandEnv(cfg.Secret)))
+	}
+	switch {
+	case cfg.PrivateKeyFile != "":
+		data, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("signing: key %q: %w", cfg.ID, err)
+		}
+		return ParsePrivateKey(cfg.ID, cfg.Algorithm, data)
+	case cfg.PublicKeyFile != "":
+		data, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("signing: key %q: %w", cfg.ID, err)
+		}
+		return ParsePublicKey(cfg.ID, cfg.Algorithm, data)
+	}
+	return nil, fmt.Errorf("signing: key %q has neither a private nor a public key file", cfg.ID)
+}
+
+// KeySet signs tokens with one key and verifies them with any of its keys.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewKeySet creates a set that signs with the key named signingId.
+func NewKeySet(signingId string, keys ...*Key) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if _, ok := set.keys[key.ID]; ok {
+			return nil, fmt.Errorf("signing: duplicate key id %q", key.ID)
+		}
+		set.keys[key.ID] = key
+	}
+	set.signing = set.keys[signingId]
+	if set.signing == nil {
+		return nil, fmt.Errorf("signing: unknown signing key %q", signingId)
+	}
+	if !set.signing.CanSign() {
+		return nil, fmt.Errorf("signing: key %q has no private key to sign with", signingId)
+	}
+	return set, nil
+}
+
+// Sign creates a token with the signing key and its ID in the header.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.Method, claims)
+	token.Header["kid"] = s.signing.ID
+	return token.SignedString(s.signing.sign)
+}
+
+// Keyfunc finds the key a token was signed with for jwt.Parse. The token
+// must name the key and use its algorithm.
+func (s *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	id, _ := token.Header["kid"].(string)
+	if id == "" {
+		return nil, errors.New("token has no key id")
+	}
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", id)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("key %q does not use %s", id, token.Method.Alg())
+	}
+	return key.verify, nil
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public keys of the set, for other services to verify
+// tokens with. HS256 keys are secret and never listed.
+func (s *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		if jwk, ok := publicJWK(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func publicJWK(key *Key) (JWK, bool) {
+	jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+	switch public := key.verify.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encode(public.N.Bytes())
+		jwk.E = encode(bigEndian(public.E))
+		return jwk, true
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+		jwk.X = encode(public)
+		return jwk, true
+	}
+	return jwk, false
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// bigEndian encodes a positive int in as few bytes as possible.
+func bigEndian(n int) []byte {
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return b
+}
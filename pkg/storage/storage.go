@@ -0,0 +1,24 @@
+// Package storage keeps binary objects (blobs) such as task attachments
+// outside the database.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotExist is returned for keys that have no object.
+var ErrNotExist = errors.New("storage: object does not exist")
+
+// Store is a flat key/value store for blobs. Keys are slash separated paths
+// like "tasks/12/3f2a".
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any object with
+	// the same key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key. The caller closes the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key.
+	Delete(ctx context.Context, key string) error
+}
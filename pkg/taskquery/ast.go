@@ -0,0 +1,116 @@
+// Package taskquery parses the compact filter syntax shared by the API, the
+// CLI and bots, e.g.
+//
+//	is:open created:>2024-10-01 title:"release" -desc:wip
+//
+// A query is a list of terms that must all match. Terms are either key:value
+// pairs, optionally with a comparison operator before the value, or bare
+// words and "quoted strings". A leading minus or NOT negates a term, OR
+// joins alternatives and parentheses group them; AND may be written out but
+// is implied. Keys and the meaning of values are left to the caller, which
+// compiles the tree into whatever it queries.
+package taskquery
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Node is an element of the syntax tree.
+type Node interface {
+	// Pos is the 1-based character position where the node starts.
+	Pos() int
+	String() string
+}
+
+// Op is the comparison of a key:value term.
+type Op int
+
+const (
+	Eq Op = iota
+	Lt
+	Le
+	Gt
+	Ge
+)
+
+func (o Op) String() string {
+	switch o {
+	case Lt:
+		return "<"
+	case Le:
+		return "<="
+	case Gt:
+		return ">"
+	case Ge:
+		return ">="
+	}
+	return ""
+}
+
+// And matches when all its nodes match.
+type And struct {
+	Nodes []Node
+}
+
+// Or matches when any of its nodes matches.
+type Or struct {
+	Nodes []Node
+}
+
+// Not matches when its node does not.
+type Not struct {
+	Node Node
+	At   int
+}
+
+// Term is a key:value pair. Key is lower case; Quoted tells whether the
+// value was written as a "quoted string".
+type Term struct {
+	Key    string
+	Op     Op
+	Value  string
+	Quoted bool
+	At     int
+	// ValueAt is the position of the value, for errors about it.
+	ValueAt int
+}
+
+// Text is a bare word or quoted string without a key.
+type Text struct {
+	Value  string
+	Quoted bool
+	At     int
+}
+
+func (n *And) Pos() int  { return n.Nodes[0].Pos() }
+func (n *Or) Pos() int   { return n.Nodes[0].Pos() }
+func (n *Not) Pos() int  { return n.At }
+func (n *Term) Pos() int { return n.At }
+func (n *Text) Pos() int { return n.At }
+
+// String formats the tree as an s-expression, mainly for tests and logs.
+func (n *And) String() string { return list("AND", n.Nodes) }
+func (n *Or) String() string  { return list("OR", n.Nodes) }
+func (n *Not) String() string { return "(NOT " + n.Node.String() + ")" }
+
+func (n *Term) String() string {
+	return n.Key + ":" + n.Op.String() + quote(n.Value, n.Quoted)
+}
+
+func (n *Text) String() string { return quote(n.Value, n.Quoted) }
+
+func list(op string, nodes []Node) string {
+	parts := make([]string, len(nodes))
+	for i, node := range nodes {
+		parts[i] = node.String()
+	}
+	return "(" + op + " " + strings.Join(parts, " ") + ")"
+}
+
+func quote(value string, quoted bool) string {
+	if quoted {
+		return strconv.Quote(value)
+	}
+	return value
+}
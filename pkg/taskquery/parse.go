@@ -0,0 +1,315 @@
+package taskquery
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MaxLength bounds the length of a query in characters.
+	MaxLength = 1000
+	// maxDepth bounds the nesting of parentheses and negations.
+	maxDepth = 32
+)
+
+// Error reports a malformed query and the 1-based character position of the
+// offending part.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("taskquery: %s at position %d", e.Msg, e.Pos)
+}
+
+func errorAt(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokLParen
+	tokRParen
+	tokMinus
+	tokAnd
+	tokOr
+	tokNot
+	tokTerm
+	tokText
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	node Node
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokLParen:
+		return `"("`
+	case tokRParen:
+		return `")"`
+	case tokMinus:
+		return `"-"`
+	case tokAnd:
+		return "AND"
+	case tokOr:
+		return "OR"
+	case tokNot:
+		return "NOT"
+	}
+	return t.node.String()
+}
+
+type lexer struct {
+	input []rune
+	i     int
+}
+
+func isDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+}
+
+func isKeyRune(r rune) bool {
+	return r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
+
+func (l *lexer) peek() (rune, bool) {
+	if l.i >= len(l.input) {
+		return 0, false
+	}
+	return l.input[l.i], true
+}
+
+func (l *lexer) next() (token, error) {
+	for l.i < len(l.input) && unicode.IsSpace(l.input[l.i]) {
+		l.i++
+	}
+	pos := l.i + 1
+	r, ok := l.peek()
+	if !ok {
+		return token{kind: tokEOF, pos: pos}, nil
+	}
+	switch r {
+	case '(':
+		l.i++
+		return token{kind: tokLParen, pos: pos}, nil
+	case ')':
+		l.i++
+		return token{kind: tokRParen, pos: pos}, nil
+	case '-':
+		l.i++
+		if next, ok := l.peek(); !ok || unicode.IsSpace(next) {
+			return token{}, errorAt(pos, `"-" must be followed directly by a term`)
+		}
+		return token{kind: tokMinus, pos: pos}, nil
+	case '"':
+		value, err := l.quoted()
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokText, pos: pos, node: &Text{Value: value, Quoted: true, At: pos}}, nil
+	}
+
+	start := l.i
+	for l.i < len(l.input) && isKeyRune(l.input[l.i]) {
+		l.i++
+	}
+	if l.i > start && l.i < len(l.input) && l.input[l.i] == ':' {
+		return l.term(string(l.input[start:l.i]), pos)
+	}
+	for l.i < len(l.input) && !isDelimiter(l.input[l.i]) {
+		l.i++
+	}
+	word := string(l.input[start:l.i])
+	switch word {
+	case "AND":
+		return token{kind: tokAnd, pos: pos}, nil
+	case "OR":
+		return token{kind: tokOr, pos: pos}, nil
+	case "NOT":
+		return token{kind: tokNot, pos: pos}, nil
+	}
+	return token{kind: tokText, pos: pos, node: &Text{Value: word, At: pos}}, nil
+}
+
+// term reads the operator and value of a key:value term; the lexer stands on
+// the colon.
+func (l *lexer) term(key string, pos int) (token, error) {
+	l.i++
+	term := &Term{Key: strings.ToLower(key), At: pos}
+	for _, op := range []Op{Ge, Le, Gt, Lt} {
+		if l.hasPrefix(op.String()) {
+			term.Op = op
+			l.i += len(op.String())
+			break
+		}
+	}
+	if term.Op == Eq && l.hasPrefix("=") {
+		l.i++
+	}
+	term.ValueAt = l.i + 1
+	if r, ok := l.peek(); ok && r == '"' {
+		value, err := l.quoted()
+		if err != nil {
+			return token{}, err
+		}
+		term.Value, term.Quoted = value, true
+	} else {
+		start := l.i
+		for l.i < len(l.input) && !isDelimiter(l.input[l.i]) {
+			l.i++
+		}
+		term.Value = string(l.input[start:l.i])
+	}
+	if term.Value == "" && !term.Quoted {
+		return token{}, errorAt(term.ValueAt, "missing value for %q", key)
+	}
+	return token{kind: tokTerm, pos: pos, node: term}, nil
+}
+
+func (l *lexer) hasPrefix(s string) bool {
+	return strings.HasPrefix(string(l.input[l.i:min(l.i+len(s), len(l.input))]), s)
+}
+
+// quoted reads a "quoted string" in which \" and \\ stand for themselves.
+func (l *lexer) quoted() (string, error) {
+	pos := l.i + 1
+	l.i++
+	var b strings.Builder
+	for l.i < len(l.input) {
+		r := l.input[l.i]
+		l.i++
+		switch {
+		case r == '"':
+			return b.String(), nil
+		case r == '\\' && l.i < len(l.input):
+			b.WriteRune(l.input[l.i])
+			l.i++
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return "", errorAt(pos, "unterminated string")
+}
+
+type parser struct {
+	lexer lexer
+	tok   token
+	depth int
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+// Parse reads a query into its syntax tree. Errors are of type *Error.
+func Parse(query string) (Node, error) {
+	input := []rune(query)
+	if len(input) > MaxLength {
+		return nil, errorAt(MaxLength+1, "query is longer than %d characters", MaxLength)
+	}
+	p := &parser{lexer: lexer{input: input}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokEOF {
+		return nil, errorAt(p.tok.pos, "query is empty")
+	}
+	node, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, errorAt(p.tok.pos, "unexpected %s", p.tok.describe())
+	}
+	return node, nil
+}
+
+func (p *parser) or() (Node, error) {
+	var nodes []Node
+	for {
+		node, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		if p.tok.kind != tokOr {
+			break
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &Or{Nodes: nodes}, nil
+}
+
+func (p *parser) and() (Node, error) {
+	var nodes []Node
+	for {
+		if p.tok.kind == tokAnd && len(nodes) > 0 {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		node, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		if k := p.tok.kind; k == tokEOF || k == tokRParen || k == tokOr {
+			break
+		}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &And{Nodes: nodes}, nil
+}
+
+func (p *parser) unary() (Node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokTerm, tokText:
+		return tok.node, p.advance()
+	case tokMinus, tokNot, tokLParen:
+		if p.depth++; p.depth > maxDepth {
+			return nil, errorAt(tok.pos, "query is nested deeper than %d levels", maxDepth)
+		}
+		defer func() { p.depth-- }()
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if tok.kind != tokLParen {
+			node, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			return &Not{Node: node, At: tok.pos}, nil
+		}
+		node, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, errorAt(tok.pos, `unclosed "("`)
+		}
+		return node, p.advance()
+	}
+	return nil, errorAt(tok.pos, "expected a term, found %s", tok.describe())
+}
@@ -0,0 +1,271 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+	"trackerApp/internal/handlers"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services"
+	"trackerApp/pkg/oidc"
+)
+
+// mockOIDC is a minimal OpenID Connect provider. It signs in whoever user
+// holds without asking, and checks the client and the PKCE verifier when a
+// code is exchanged.
+type mockOIDC struct {
+	*httptest.Server
+	key          *rsa.PrivateKey
+	clientID     string
+	clientSecret string
+
+	mu    sync.Mutex
+	user  jwt.MapClaims
+	codes map[string]mockGrant
+}
+
+type mockGrant struct {
+	challenge, nonce, redirectURI string
+	user                          jwt.MapClaims
+}
+
+func newMockOIDC(t *testing.T) *mockOIDC {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOIDC{key: key, clientID: "tracker", clientSecret: "mock secret", codes: map[string]mockGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "mock", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()), "e": "AQAB",
+		}}})
+	})
+	mux.HandleFunc("GET /authorize", m.authorizeEndpoint)
+	mux.HandleFunc("POST /token", m.tokenEndpoint)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockOIDC) authorizeEndpoint(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "code" || q.Get("client_id") != m.clientID || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	code, _ := oidc.NewVerifier()
+	m.mu.Lock()
+	m.codes[code] = mockGrant{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), redirectURI: q.Get("redirect_uri"), user: m.user}
+	m.mu.Unlock()
+	back, _ := url.Parse(q.Get("redirect_uri"))
+	back.RawQuery = url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+	http.Redirect(w, r, back.String(), http.StatusFound)
+}
+
+func (m *mockOIDC) tokenEndpoint(w http.ResponseWriter, r *http.Request) {
+	fail := func(code string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != m.clientID || secret != url.QueryEscape(m.clientSecret) {
+		fail("invalid_client")
+		return
+	}
+	m.mu.Lock()
+	grant, ok := m.codes[r.PostFormValue("code")]
+	delete(m.codes, r.PostFormValue("code"))
+	m.mu.Unlock()
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != grant.redirectURI ||
+		oidc.Challenge(r.PostFormValue("code_verifier")) != grant.challenge {
+		fail("invalid_grant")
+		return
+	}
+	claims := jwt.MapClaims{"nonce": grant.nonce}
+	for name, value := range grant.user {
+		claims[name] = value
+	}
+	json.NewEncoder(w).Encode(map[string]string{"access_token": "unused", "token_type": "Bearer", "id_token": m.sign(claims)})
+}
+
+// sign issues an ID token for the client, claims overriding the defaults.
+func (m *mockOIDC) sign(claims jwt.MapClaims) string {
+	now := time.Now()
+	all := jwt.MapClaims{"iss": m.URL, "aud": m.clientID, "iat": now.Unix(), "exp": now.Add(time.Minute).Unix()}
+	for name, value := range claims {
+		all[name] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, all)
+	token.Header["kid"] = "mock"
+	signed, _ := token.SignedString(m.key)
+	return signed
+}
+
+// signIn follows the sign-in URL the way a browser would and returns what
+// the provider sends back to the redirect URL.
+func (m *mockOIDC) signIn(t *testing.T, user jwt.MapClaims, authURL string) (code, state string) {
+	m.mu.Lock()
+	m.user = user
+	m.mu.Unlock()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || back.Query().Get("code") == "" {
+		t.Fatalf("sign-in failed: %s", resp.Status)
+	}
+	return back.Query().Get("code"), back.Query().Get("state")
+}
+
+func (m *mockOIDC) config() oidc.Config {
+	return oidc.Config{
+		Issuer:       m.URL,
+		ClientID:     m.clientID,
+		ClientSecret: m.clientSecret,
+		RedirectURL:  "http://tracker.test/api/oidc/callback",
+		Scopes:       []string{"email"},
+	}
+}
+
+func TestOIDC(t *testing.T) {
+	ctx := context.Background()
+	mock := newMockOIDC(t)
+	provider, err := oidc.Discover(ctx, mock.config(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := jwt.MapClaims{"sub": "alice", "email": "alice@example.com", "email_verified": true}
+
+	t.Run("SignIn", func(t *testing.T) {
+		// arrange
+		verifier, _ := oidc.NewVerifier()
+		code, state := mock.signIn(t, alice, provider.AuthCodeURL("state", "nonce", verifier))
+		// act
+		claims, err := provider.Exchange(ctx, code, verifier, "nonce")
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "state", state)
+		assert.Equal(t, "alice", claims.Subject)
+		assert.Equal(t, "alice@example.com", claims.Email)
+		assert.True(t, claims.EmailVerified)
+	})
+	t.Run("RejectsBadExchanges", func(t *testing.T) {
+		// arrange
+		verifier, _ := oidc.NewVerifier()
+		other, _ := oidc.NewVerifier()
+		wrongVerifierCode, _ := mock.signIn(t, alice, provider.AuthCodeURL("state", "nonce", verifier))
+		wrongNonceCode, _ := mock.signIn(t, alice, provider.AuthCodeURL("state", "nonce", verifier))
+		usedCode, _ := mock.signIn(t, alice, provider.AuthCodeURL("state", "nonce", verifier))
+		provider.Exchange(ctx, usedCode, verifier, "nonce")
+		// act
+		_, wrongVerifierErr := provider.Exchange(ctx, wrongVerifierCode, other, "nonce")
+		_, wrongNonceErr := provider.Exchange(ctx, wrongNonceCode, verifier, "other nonce")
+		_, usedErr := provider.Exchange(ctx, usedCode, verifier, "nonce")
+		// assert
+		assert.Error(t, wrongVerifierErr)
+		assert.Error(t, wrongNonceErr)
+		assert.Error(t, usedErr)
+	})
+	t.Run("RejectsForeignTokens", func(t *testing.T) {
+		// arrange
+		otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		forged := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"iss": mock.URL, "aud": mock.clientID, "sub": "alice", "exp": time.Now().Add(time.Minute).Unix()})
+		forged.Header["kid"] = "mock"
+		forgedToken, _ := forged.SignedString(otherKey)
+		// act
+		_, valid := provider.Verify(ctx, mock.sign(jwt.MapClaims{"sub": "alice"}), "")
+		_, audienceErr := provider.Verify(ctx, mock.sign(jwt.MapClaims{"sub": "alice", "aud": "other client"}), "")
+		_, issuerErr := provider.Verify(ctx, mock.sign(jwt.MapClaims{"sub": "alice", "iss": "https://other.example"}), "")
+		_, expiredErr := provider.Verify(ctx, mock.sign(jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(-time.Minute).Unix()}), "")
+		_, subjectErr := provider.Verify(ctx, mock.sign(jwt.MapClaims{}), "")
+		_, forgedErr := provider.Verify(ctx, forgedToken, "")
+		// assert
+		assert.NoError(t, valid)
+		assert.Error(t, audienceErr)
+		assert.Error(t, issuerErr)
+		assert.Error(t, expiredErr)
+		assert.Error(t, subjectErr)
+		assert.Error(t, forgedErr)
+	})
+	t.Run("WrongIssuer", func(t *testing.T) {
+		// arrange
+		cfg := mock.config()
+		cfg.Issuer += "/"
+		// act
+		_, err := oidc.Discover(ctx, cfg, nil)
+		// assert
+		assert.Error(t, err)
+	})
+}
+
+// fakeOIDCLogins starts sign-ins with a fixed state and remembers the state
+// the browser brought back to the callback.
+type fakeOIDCLogins struct {
+	services.IOIDCService
+	boundState string
+}
+
+func (f *fakeOIDCLogins) StartLogin() (string, string, error) {
+	return "https://idp.example/authorize?state=s1", "s1", nil
+}
+
+func (f *fakeOIDCLogins) FinishLogin(state, boundState, code string) (*models.Tokens, error) {
+	f.boundState = boundState
+	if state != boundState {
+		return nil, services.ErrUnauthorized
+	}
+	return &models.Tokens{}, nil
+}
+
+func TestOIDCStateCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logins := &fakeOIDCLogins{}
+	router := handlers.NewHandler(&services.Service{IOIDCService: logins}).InitRoutes()
+	request := func(path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// act
+	login := request("/api/oidc/login", nil)
+	cookies := login.Result().Cookies()
+	bound := request("/api/oidc/callback?code=c1&state=s1", cookies)
+	unbound := request("/api/oidc/callback?code=c1&state=s1", nil)
+	// assert
+	assert.Equal(t, http.StatusFound, login.Code)
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "s1", cookies[0].Value)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, http.StatusOK, bound.Code)
+	assert.Equal(t, http.StatusUnauthorized, unbound.Code)
+	assert.Equal(t, "", logins.boundState)
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sort"
+	"testing"
+	"trackerApp/pkg/rank"
+)
+
+func TestRank(t *testing.T) {
+	t.Run("Between", func(t *testing.T) {
+		// arrange
+		pairs := [][2]string{{"", ""}, {"", "i"}, {"i", ""}, {"1", "2"}, {"1", "10z"}, {"1", "1i"}, {"zz", ""}, {"", "01"}, {"az", "b"}}
+		for _, pair := range pairs {
+			// act
+			key, err := rank.Between(pair[0], pair[1])
+			// assert
+			assert.NoError(t, err, pair)
+			assert.True(t, rank.Valid(key), key)
+			assert.True(t, pair[0] < key, pair)
+			assert.True(t, pair[1] == "" || key < pair[1], pair)
+		}
+	})
+	t.Run("RejectsBadKeys", func(t *testing.T) {
+		// act
+		_, sameErr := rank.Between("i", "i")
+		_, orderErr := rank.Between("j", "i")
+		_, zeroErr := rank.Between("i0", "")
+		_, charErr := rank.Between("I", "")
+		// assert
+		assert.Error(t, sameErr)
+		assert.Error(t, orderErr)
+		assert.Error(t, zeroErr)
+		assert.Error(t, charErr)
+	})
+	t.Run("RandomInserts", func(t *testing.T) {
+		// arrange
+		random := rand.New(rand.NewSource(1))
+		keys := []string{}
+		// act
+		for i := 0; i < 500; i++ {
+			pos := random.Intn(len(keys) + 1)
+			var lo, hi string
+			if pos > 0 {
+				lo = keys[pos-1]
+			}
+			if pos < len(keys) {
+				hi = keys[pos]
+			}
+			key, err := rank.Between(lo, hi)
+			assert.NoError(t, err)
+			keys = append(keys[:pos], append([]string{key}, keys[pos:]...)...)
+		}
+		// assert
+		assert.True(t, sort.StringsAreSorted(keys))
+	})
+	t.Run("After", func(t *testing.T) {
+		// arrange
+		key := ""
+		// act
+		for i := 0; i < 1000; i++ {
+			next := rank.After(key)
+			assert.True(t, rank.Valid(next) && next > key, next)
+			key = next
+		}
+		// assert
+		assert.LessOrEqual(t, len(key), 4)
+		assert.Equal(t, "0000000004", rank.After("0000000003"))
+		assert.Equal(t, "j1", rank.After("iz"))
+	})
+	t.Run("Spread", func(t *testing.T) {
+		// act
+		keys := rank.Spread(100)
+		// assert
+		assert.Len(t, keys, 100)
+		assert.True(t, sort.StringsAreSorted(keys))
+		for i, key := range keys {
+			assert.True(t, rank.Valid(key), key)
+			if i > 0 {
+				assert.NotEqual(t, keys[i-1], key)
+			}
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"trackerApp/pkg/rrule"
+)
+
+func TestRrule(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			panic(err)
+		}
+		return d
+	}
+	t.Run("Parse", func(t *testing.T) {
+		// arrange
+		valid := "RRULE:FREQ=weekly;INTERVAL=2;BYDAY=MO,we;COUNT=4"
+		invalid := []string{"", "INTERVAL=2", "FREQ=HOURLY", "FREQ=DAILY;COUNT=0", "FREQ=WEEKLY;BYDAY=1MO",
+			"FREQ=DAILY;COUNT=2;UNTIL=20241231", "FREQ=MONTHLY;BYDAY=XX", "FREQ=DAILY;BYHOUR=5"}
+		// act
+		rule, err := rrule.Parse(valid)
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=4", rule.String())
+		for _, s := range invalid {
+			_, err := rrule.Parse(s)
+			assert.Error(t, err, s)
+		}
+	})
+	t.Run("WeeklyByDay", func(t *testing.T) {
+		// arrange
+		rule, _ := rrule.Parse("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=5")
+		// act
+		actual := rule.Take(day("2024-10-02 09:00"), 10)
+		// assert
+		assert.Equal(t, []time.Time{
+			day("2024-10-02 09:00"), day("2024-10-14 09:00"), day("2024-10-16 09:00"),
+			day("2024-10-28 09:00"), day("2024-10-30 09:00"),
+		}, actual)
+	})
+	t.Run("MonthlySkipsShortMonths", func(t *testing.T) {
+		// arrange
+		rule, _ := rrule.Parse("FREQ=MONTHLY")
+		// act
+		actual := rule.Take(day("2024-01-31 00:00"), 4)
+		// assert
+		assert.Equal(t, []time.Time{day("2024-01-31 00:00"), day("2024-03-31 00:00"), day("2024-05-31 00:00"), day("2024-07-31 00:00")}, actual)
+	})
+	t.Run("MonthlyLastFriday", func(t *testing.T) {
+		// arrange
+		rule, _ := rrule.Parse("FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20241231")
+		// act
+		actual := rule.Take(day("2024-10-25 18:00"), 10)
+		// assert
+		assert.Equal(t, []time.Time{day("2024-10-25 18:00"), day("2024-11-29 18:00"), day("2024-12-27 18:00")}, actual)
+	})
+	t.Run("YearlyLeapDay", func(t *testing.T) {
+		// arrange
+		rule, _ := rrule.Parse("FREQ=YEARLY")
+		// act
+		next, ok := rule.Next(day("2024-02-29 12:00"))
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, day("2028-02-29 12:00"), next)
+	})
+	t.Run("NextAfterLastOccurrence", func(t *testing.T) {
+		// arrange
+		rule, _ := rrule.Parse("FREQ=DAILY;COUNT=1")
+		// act
+		_, ok := rule.Next(day("2024-10-01 00:00"))
+		// assert
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trackerApp/internal/handlers"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services"
+)
+
+// fakeApiTokens accepts the tokens it knows with the scopes listed for them.
+// Methods the routes under test do not reach are left unimplemented.
+type fakeApiTokens struct {
+	services.IApiTokenService
+	scopes map[string][]string
+}
+
+func (f *fakeApiTokens) ParseApiToken(token string) (*models.Principal, error) {
+	scopes, ok := f.scopes[token]
+	if !ok {
+		return nil, services.ErrUnauthorized
+	}
+	return &models.Principal{UserId: 1, ApiTokenId: 1, Scopes: scopes}, nil
+}
+
+func (f *fakeApiTokens) Get(userId int) ([]models.ApiToken, error) {
+	return []models.ApiToken{}, nil
+}
+
+func TestRouteScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens := &fakeApiTokens{scopes: map[string][]string{
+		"trk_dashboard": {models.ScopeTasksRead},
+		"trk_session":   models.SessionScopes,
+	}}
+	router := handlers.NewHandler(&services.Service{IApiTokenService: tokens}).InitRoutes()
+	request := func(method, path, token string) (int, string) {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var body struct {
+			MissingScope string `json:"missing_scope"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body.MissingScope
+	}
+
+	tests := []struct {
+		name, method, path, token string
+		status                    int
+		missing                   string
+	}{
+		{"WriteWithReadScope", http.MethodPost, "/api/protected/tasks/", "trk_dashboard", http.StatusForbidden, models.ScopeTasksWrite},
+		{"DeleteWithReadScope", http.MethodDelete, "/api/protected/tasks/1", "trk_dashboard", http.StatusForbidden, models.ScopeTasksWrite},
+		{"OtherResource", http.MethodGet, "/api/protected/projects/1/tasks", "trk_dashboard", http.StatusForbidden, models.ScopeProjectsRead},
+		{"TokensNeedSession", http.MethodGet, "/api/protected/tokens/", "trk_dashboard", http.StatusForbidden, models.ScopeTokensRead},
+		{"LinkNeedsSession", http.MethodPost, "/api/protected/oidc/link", "trk_dashboard", http.StatusForbidden, models.ScopeTokensWrite},
+		{"LinkCallbackNeedsSession", http.MethodPost, "/api/protected/oidc/link/callback", "trk_dashboard", http.StatusForbidden, models.ScopeTokensWrite},
+		{"Granted", http.MethodGet, "/api/protected/tokens/", "trk_session", http.StatusOK, ""},
+		{"UnknownToken", http.MethodGet, "/api/protected/tasks/", "trk_unknown", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// act
+			status, missing := request(tt.method, tt.path, tt.token)
+			// assert
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.missing, missing)
+		})
+	}
+}
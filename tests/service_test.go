@@ -1,13 +1,22 @@
 package tests
 
 import (
+	"context"
 	"database/sql"
+	"github.com/golang-jwt/jwt/v5"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/stretchr/testify/assert"
+	"io"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 	"trackerApp/internal/models"
 	"trackerApp/internal/services"
 	"trackerApp/internal/services/dtos"
+	"trackerApp/pkg/oidc"
+	"trackerApp/pkg/signing"
+	"trackerApp/pkg/storage"
 )
 
 const (
@@ -17,18 +26,227 @@ const (
 		username VARCHAR(255) NOT NULL UNIQUE,
 		password VARCHAR(255) NOT NULL UNIQUE
 	);
+	CREATE TABLE projects(
+		id SERIAL PRIMARY KEY,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(255) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		color VARCHAR(16) NOT NULL DEFAULT '',
+		archived BOOLEAN NOT NULL DEFAULT FALSE,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE workspaces(
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE workspace_members(
+		workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		role TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'guest')),
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (workspace_id, user_id)
+	);
+	CREATE TABLE workspace_invitations(
+		id SERIAL PRIMARY KEY,
+		workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		role TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'guest')),
+		invited_by INT REFERENCES users(id) ON DELETE SET NULL,
+		status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		responded_at TIMESTAMPTZ
+	);
+	CREATE UNIQUE INDEX workspace_invitations_pending_idx ON workspace_invitations (workspace_id, user_id) WHERE status = 'pending';
 	CREATE TABLE tasks (
 		id SERIAL PRIMARY KEY,
 		title VARCHAR(255) NOT NULL,
 		description TEXT NOT NULL, 
 		is_complete BOOLEAN DEFAULT FALSE,
-		create_at VARCHAR(255) NOT NULL,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		user_id INT REFERENCES users(id) ON DELETE CASCADE,
+		start_at TIMESTAMPTZ,
+		due_at TIMESTAMPTZ,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		completed_at TIMESTAMPTZ,
+		project_id INT REFERENCES projects(id) ON DELETE SET NULL,
+		parent_task_id INT REFERENCES tasks(id) ON DELETE CASCADE,
+		recurrence TEXT,
+		recurrence_tz TEXT,
+		status_id INT,
+		priority SMALLINT NOT NULL DEFAULT 1,
+		column_id INT,
+		rank TEXT COLLATE "C" NOT NULL DEFAULT '',
+		workspace_id INT REFERENCES workspaces(id) ON DELETE CASCADE,
+		deleted_at TIMESTAMPTZ,
+		search_vector TSVECTOR GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'B')
+		) STORED
+	);
+	CREATE TABLE task_dependencies(
+		task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		blocker_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		PRIMARY KEY (task_id, blocker_id),
+		CHECK (task_id <> blocker_id)
+	);
+	CREATE TABLE labels(
+		id SERIAL PRIMARY KEY,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(64) NOT NULL,
+		color VARCHAR(16) NOT NULL DEFAULT '',
+		UNIQUE (user_id, name)
+	);
+	CREATE TABLE task_labels(
+		task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		label_id INT NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+		PRIMARY KEY (task_id, label_id)
+	);
+	CREATE TABLE workflow_statuses(
+		id SERIAL PRIMARY KEY,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		project_id INT REFERENCES projects(id) ON DELETE CASCADE,
+		name VARCHAR(64) NOT NULL,
+		position INT NOT NULL,
+		is_terminal BOOLEAN NOT NULL DEFAULT FALSE
+	);
+	CREATE UNIQUE INDEX workflow_statuses_user_name_idx ON workflow_statuses (user_id, name) WHERE project_id IS NULL;
+	CREATE UNIQUE INDEX workflow_statuses_project_name_idx ON workflow_statuses (project_id, name) WHERE project_id IS NOT NULL;
+	CREATE TABLE workflow_transitions(
+		from_status_id INT NOT NULL REFERENCES workflow_statuses(id) ON DELETE CASCADE,
+		to_status_id INT NOT NULL REFERENCES workflow_statuses(id) ON DELETE CASCADE,
+		PRIMARY KEY (from_status_id, to_status_id)
+	);
+	ALTER TABLE tasks ADD FOREIGN KEY (status_id) REFERENCES workflow_statuses(id) ON DELETE SET NULL;
+	CREATE TABLE board_columns(
+		id SERIAL PRIMARY KEY,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(64) NOT NULL,
+		position INT NOT NULL,
+		is_done BOOLEAN NOT NULL DEFAULT FALSE,
+		UNIQUE (user_id, name)
+	);
+	ALTER TABLE tasks ADD FOREIGN KEY (column_id) REFERENCES board_columns(id) ON DELETE SET NULL;
+	CREATE TABLE comments(
+		id SERIAL PRIMARY KEY,
+		task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		body TEXT NOT NULL,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		edited_at TIMESTAMPTZ
+	);
+	CREATE TABLE comment_versions(
+		id SERIAL PRIMARY KEY,
+		comment_id INT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+		body TEXT NOT NULL,
+		create_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE TABLE attachments(
+		id SERIAL PRIMARY KEY,
+		task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		file_name TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size BIGINT NOT NULL,
+		storage_key TEXT NOT NULL UNIQUE,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE task_shares(
+		task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		role TEXT NOT NULL CHECK (role IN ('viewer', 'editor')),
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (task_id, user_id)
+	);
+	CREATE TABLE project_shares(
+		project_id INT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		role TEXT NOT NULL CHECK (role IN ('viewer', 'editor')),
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (project_id, user_id)
+	);
+	CREATE TABLE task_assignees(
+		task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		assigned_by INT REFERENCES users(id) ON DELETE SET NULL,
+		assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (task_id, user_id)
+	);
+	CREATE TABLE task_assignment_changes(
+		id SERIAL PRIMARY KEY,
+		task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		action TEXT NOT NULL CHECK (action IN ('assigned', 'unassigned')),
+		changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE saved_views(
+		id SERIAL PRIMARY KEY,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(255) NOT NULL,
+		filter JSONB NOT NULL DEFAULT '{}',
+		sort TEXT NOT NULL DEFAULT 'created',
+		sort_order TEXT NOT NULL DEFAULT 'asc' CHECK (sort_order IN ('asc', 'desc')),
+		is_default BOOLEAN NOT NULL DEFAULT FALSE,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		UNIQUE (user_id, name)
+	);
+	CREATE UNIQUE INDEX saved_views_default_idx ON saved_views (user_id) WHERE is_default;
+	CREATE TABLE sessions(
+		id SERIAL PRIMARY KEY,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		revoked_at TIMESTAMPTZ
+	);
+	CREATE TABLE refresh_tokens(
+		id SERIAL PRIMARY KEY,
+		session_id INT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+		token_hash TEXT NOT NULL UNIQUE,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ
+	);
+	CREATE TABLE api_tokens(
+		id SERIAL PRIMARY KEY,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(255) NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		scopes TEXT NOT NULL,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		expires_at TIMESTAMPTZ,
+		last_used_at TIMESTAMPTZ,
+		revoked_at TIMESTAMPTZ
+	);
+	CREATE TABLE user_identities(
+		id SERIAL PRIMARY KEY,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		issuer TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		UNIQUE (issuer, subject)
+	);
+	CREATE TABLE oidc_logins(
+		id SERIAL PRIMARY KEY,
+		state_hash TEXT NOT NULL UNIQUE,
+		nonce TEXT NOT NULL,
+		code_verifier TEXT NOT NULL,
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		expires_at TIMESTAMPTZ NOT NULL,
 		user_id INT REFERENCES users(id) ON DELETE CASCADE
 	);
+	CREATE TABLE task_history(
+		id BIGSERIAL PRIMARY KEY,
+		task_id INT NOT NULL,
+		actor_id INT REFERENCES users(id) ON DELETE SET NULL,
+		action TEXT NOT NULL CHECK (action IN ('created', 'updated', 'deleted', 'restored', 'purged')),
+		changes JSONB NOT NULL DEFAULT '{}',
+		create_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
 	INSERT INTO users (username, password) VALUES ('test','test');
-	INSERT INTO tasks (title, description, is_complete, create_at, user_id) VALUES ('task 1','description 1', false, '25/03/2004', 1);
-	INSERT INTO tasks (title, description, is_complete, create_at, user_id) VALUES ('task 2','description 2', false, '25/03/2004', 1);
-	INSERT INTO tasks (title, description, is_complete, create_at, user_id) VALUES ('task 3','description 3', false, '25/03/2004', 1);
+	INSERT INTO tasks (title, description, is_complete, create_at, user_id) VALUES ('task 1','description 1', false, '2004-03-25T00:00:00Z', 1);
+	INSERT INTO tasks (title, description, is_complete, create_at, user_id) VALUES ('task 2','description 2', false, '2004-03-25T00:00:00Z', 1);
+	INSERT INTO tasks (title, description, is_complete, create_at, user_id) VALUES ('task 3','description 3', false, '2004-03-25T00:00:00Z', 1);
 `
 	dropTables = `
 	DROP TABLE tasks IF EXISTS; 
@@ -51,20 +269,34 @@ func setupDb() {
 }
 
 func teardown() {
-	db.Exec(`DROP TABLE tasks; DROP TABLE users;`)
+	db.Exec(`DROP TABLE oidc_logins; DROP TABLE user_identities; DROP TABLE api_tokens; DROP TABLE refresh_tokens; DROP TABLE sessions; DROP TABLE task_history; DROP TABLE saved_views; DROP TABLE task_assignment_changes; DROP TABLE task_assignees; DROP TABLE project_shares; DROP TABLE task_shares; DROP TABLE attachments; DROP TABLE comment_versions; DROP TABLE comments; DROP TABLE task_dependencies; DROP TABLE task_labels; DROP TABLE labels; DROP TABLE tasks; DROP TABLE board_columns;
+		DROP TABLE workflow_transitions; DROP TABLE workflow_statuses; DROP TABLE projects; DROP TABLE workspace_invitations; DROP TABLE workspace_members;
+		DROP TABLE workspaces; DROP TABLE users;`)
 	db.Close()
 }
 
 func TestServices(t *testing.T) {
 	setupDb()
 	defer teardown()
-	service := services.NewService(db)
+	blobStore, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKey, err := signing.GenerateHMACKey("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKeys, err := signing.NewKeySet("test", signingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := services.NewService(db, services.Config{BlobStore: blobStore, MaxAttachmentSize: 1024, SigningKeys: signingKeys})
 
 	t.Run("AuthService", func(t *testing.T) {
 		t.Run("AddUser", func(t *testing.T) {
 			// arrange
 			expectedId := 2
-			form := dtos.UserForm{"user", "user"}
+			form := dtos.UserForm{Username: "user", Password: "user"}
 			// act
 			id, err := service.IAuthService.AddUser(form)
 			// assert
@@ -73,13 +305,115 @@ func TestServices(t *testing.T) {
 		})
 		t.Run("GenerateJwt", func(t *testing.T) {
 			// arrange
-			form := dtos.UserForm{"user", "user"}
+			form := dtos.UserForm{Username: "user", Password: "user"}
 
 			// act
 			actual, err := service.IAuthService.GenerateJwt(form)
 			// assert
-			assert.NotEmpty(t, actual)
 			assert.NoError(t, err)
+			assert.NotEmpty(t, actual.AccessToken)
+			assert.NotEmpty(t, actual.RefreshToken)
+		})
+		t.Run("Refresh", func(t *testing.T) {
+			// arrange
+			tokens, _ := service.IAuthService.GenerateJwt(dtos.UserForm{Username: "user", Password: "user"})
+			// act
+			refreshed, err := service.IAuthService.Refresh(tokens.RefreshToken)
+			principal, parseErr := service.IAuthService.ParseJwt(refreshed.AccessToken)
+			_, unknownErr := service.IAuthService.Refresh("unknown")
+			// assert
+			assert.NoError(t, err)
+			assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+			assert.NoError(t, parseErr)
+			assert.Equal(t, 2, principal.UserId)
+			assert.Equal(t, models.SessionScopes, principal.Scopes)
+			assert.ErrorIs(t, unknownErr, services.ErrUnauthorized)
+		})
+		t.Run("RefreshTokenReuse", func(t *testing.T) {
+			// arrange
+			tokens, _ := service.IAuthService.GenerateJwt(dtos.UserForm{Username: "user", Password: "user"})
+			refreshed, _ := service.IAuthService.Refresh(tokens.RefreshToken)
+			// act
+			_, reuseErr := service.IAuthService.Refresh(tokens.RefreshToken)
+			_, refreshErr := service.IAuthService.Refresh(refreshed.RefreshToken)
+			_, parseErr := service.IAuthService.ParseJwt(refreshed.AccessToken)
+			// assert
+			assert.ErrorIs(t, reuseErr, services.ErrUnauthorized)
+			assert.ErrorIs(t, refreshErr, services.ErrUnauthorized)
+			assert.ErrorIs(t, parseErr, services.ErrUnauthorized)
+		})
+		t.Run("Logout", func(t *testing.T) {
+			// arrange
+			tokens, _ := service.IAuthService.GenerateJwt(dtos.UserForm{Username: "user", Password: "user"})
+			principal, _ := service.IAuthService.ParseJwt(tokens.AccessToken)
+			// act
+			err := service.IAuthService.Logout(*principal)
+			_, parseErr := service.IAuthService.ParseJwt(tokens.AccessToken)
+			_, refreshErr := service.IAuthService.Refresh(tokens.RefreshToken)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, parseErr, services.ErrUnauthorized)
+			assert.ErrorIs(t, refreshErr, services.ErrUnauthorized)
+		})
+	})
+
+	t.Run("ApiTokenService", func(t *testing.T) {
+		t.Run("CreateApiToken", func(t *testing.T) {
+			// arrange
+			form := dtos.ApiTokenForm{Name: " ci ", Scopes: []string{models.ScopeTasksWrite, models.ScopeTasksRead, models.ScopeTasksRead}}
+			// act
+			token, err := service.IApiTokenService.Create(2, form)
+			principal, parseErr := service.IApiTokenService.ParseApiToken(token.Token)
+			tokens, _ := service.IApiTokenService.Get(2)
+			// assert
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(token.Token, services.ApiTokenPrefix))
+			assert.Equal(t, "ci", token.Name)
+			assert.Equal(t, []string{models.ScopeTasksRead, models.ScopeTasksWrite}, token.Scopes)
+			assert.NoError(t, parseErr)
+			assert.Equal(t, 2, principal.UserId)
+			assert.Equal(t, token.ID, principal.ApiTokenId)
+			assert.Equal(t, token.Scopes, principal.Scopes)
+			assert.Len(t, tokens, 1)
+			assert.NotNil(t, tokens[0].LastUsedAt)
+		})
+		t.Run("InvalidApiToken", func(t *testing.T) {
+			// arrange
+			past := time.Now().Add(-time.Hour)
+			// act
+			_, noScopeErr := service.IApiTokenService.Create(2, dtos.ApiTokenForm{Name: "ci"})
+			_, unknownScopeErr := service.IApiTokenService.Create(2, dtos.ApiTokenForm{Name: "ci", Scopes: []string{"admin"}})
+			_, expiredErr := service.IApiTokenService.Create(2, dtos.ApiTokenForm{Name: "ci", Scopes: []string{models.ScopeTasksRead}, ExpiresAt: &past})
+			_, sessionScopeErr := service.IApiTokenService.Create(2, dtos.ApiTokenForm{Name: "ci", Scopes: []string{models.ScopeTokensWrite}})
+			_, parseErr := service.IApiTokenService.ParseApiToken(services.ApiTokenPrefix + "unknown")
+			// assert
+			assert.ErrorIs(t, noScopeErr, services.ErrInvalidInput)
+			assert.ErrorIs(t, unknownScopeErr, services.ErrInvalidInput)
+			assert.ErrorIs(t, expiredErr, services.ErrInvalidInput)
+			assert.ErrorIs(t, sessionScopeErr, services.ErrInvalidInput)
+			assert.ErrorIs(t, parseErr, services.ErrUnauthorized)
+		})
+		t.Run("ExpiredApiToken", func(t *testing.T) {
+			// arrange
+			soon := time.Now().Add(time.Second)
+			token, _ := service.IApiTokenService.Create(2, dtos.ApiTokenForm{Name: "short-lived", Scopes: []string{models.ScopeTasksRead}, ExpiresAt: &soon})
+			time.Sleep(time.Until(soon))
+			// act
+			_, err := service.IApiTokenService.ParseApiToken(token.Token)
+			// assert
+			assert.ErrorIs(t, err, services.ErrUnauthorized)
+		})
+		t.Run("RevokeApiToken", func(t *testing.T) {
+			// arrange
+			token, _ := service.IApiTokenService.Create(2, dtos.ApiTokenForm{Name: "revoked", Scopes: []string{models.ScopeTasksRead}})
+			// act
+			err := service.IApiTokenService.Revoke(token.ID, 2)
+			_, parseErr := service.IApiTokenService.ParseApiToken(token.Token)
+			otherErr := service.IApiTokenService.Revoke(token.ID, 1)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, parseErr, services.ErrUnauthorized)
+			assert.ErrorIs(t, otherErr, services.ErrNotFound)
 		})
 	})
 
@@ -88,23 +422,51 @@ func TestServices(t *testing.T) {
 			// arrange
 			userId := 1
 			expected := []models.Task{
-				{1, "task 1", "description 1", false, "25/03/2004"},
-				{2, "task 2", "description 2", false, "25/03/2004"},
-				{3, "task 3", "description 3", false, "25/03/2004"},
+				{ID: 1, Title: "task 1", Description: "description 1", IsCompleted: false},
+				{ID: 2, Title: "task 2", Description: "description 2", IsCompleted: false},
+				{ID: 3, Title: "task 3", Description: "description 3", IsCompleted: false},
 			}
+			createAt := time.Date(2004, 3, 25, 0, 0, 0, 0, time.UTC)
 			// act
-			actual, err := service.ITaskService.Get(userId)
+			actual, err := service.ITaskService.Get(userId, dtos.TaskFilter{})
+			actTasks := make([]models.Task, 0, len(actual.Tasks))
+			for _, task := range actual.Tasks {
+				assert.True(t, createAt.Equal(task.CreateAt))
+				actTasks = append(actTasks, models.Task{ID: task.ID, Title: task.Title, Description: task.Description, IsCompleted: task.IsCompleted})
+			}
 			// assert
 			assert.NoError(t, err)
-			assert.Equal(t, expected, actual)
+			assert.Equal(t, expected, actTasks)
+		})
+		t.Run("GetTasksPaginated", func(t *testing.T) {
+			// arrange
+			userId := 1
+			filter := dtos.TaskFilter{Sort: "title", Order: "desc", Limit: 2}
+			// act
+			first, err := service.ITaskService.Get(userId, filter)
+			assert.NoError(t, err)
+			filter.Cursor = first.NextCursor
+			second, err := service.ITaskService.Get(userId, filter)
+			assert.NoError(t, err)
+			filtered, err := service.ITaskService.Get(userId, dtos.TaskFilter{Title: "2"})
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, 3, first.Total)
+			assert.Equal(t, []int{3, 2}, []int{first.Tasks[0].ID, first.Tasks[1].ID})
+			assert.NotEmpty(t, first.NextCursor)
+			assert.Len(t, second.Tasks, 1)
+			assert.Equal(t, 1, second.Tasks[0].ID)
+			assert.Empty(t, second.NextCursor)
+			assert.Equal(t, 1, filtered.Total)
+			assert.Equal(t, 2, filtered.Tasks[0].ID)
 		})
 		t.Run("GetTaskById", func(t *testing.T) {
 			// arrange
 			userId, taskId := 1, 1
-			expected := models.Task{1, "task 1", "description 1", false, "25/03/2004"}
+			expected := models.Task{ID: 1, Title: "task 1", Description: "description 1", IsCompleted: false}
 			// act
 			actual, err := service.ITaskService.GetById(taskId, userId)
-			actTask := models.Task{1, actual.Title, actual.Description, actual.IsCompleted, actual.CreateAt}
+			actTask := models.Task{ID: 1, Title: actual.Title, Description: actual.Description, IsCompleted: actual.IsCompleted}
 			// assert
 			assert.NoError(t, err)
 			assert.Equal(t, expected, actTask)
@@ -113,7 +475,7 @@ func TestServices(t *testing.T) {
 			// arrange
 			userId := 1
 			expectedId := 4
-			task := dtos.CreateTask{"test 4", "description 4"}
+			task := dtos.CreateTask{Title: "test 4", Description: "description 4"}
 			// act
 			actualId, err := service.ITaskService.Create(userId, task)
 			// assert
@@ -124,12 +486,16 @@ func TestServices(t *testing.T) {
 			// arrange
 			taskId := 4
 			userId := 1
-			update := dtos.UpdateTask{"test 4", "description 4", true}
+			update := dtos.UpdateTask{Title: "test 4", Description: "description 4", IsComplete: true}
 			// act
 			err := service.ITaskService.Update(taskId, userId, update)
+			task, getErr := service.ITaskService.GetById(taskId, userId)
 			// assert
 			assert.NoError(t, err)
+			assert.NoError(t, getErr)
 			assert.NotEmpty(t, update)
+			assert.NotNil(t, task.CompletedAt)
+			assert.False(t, task.UpdatedAt.Before(task.CreateAt))
 		})
 		t.Run("DeleteTask", func(t *testing.T) {
 			// arrange
@@ -140,5 +506,1273 @@ func TestServices(t *testing.T) {
 			// assert
 			assert.NoError(t, err)
 		})
+		t.Run("Deadlines", func(t *testing.T) {
+			// arrange
+			userId := 1
+			now := time.Now()
+			yesterday, tomorrow := now.AddDate(0, 0, -1), now.AddDate(0, 0, 1)
+			overdueId, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: "overdue", Description: "overdue", DueAt: &yesterday})
+			assert.NoError(t, err)
+			upcomingId, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: "upcoming", Description: "upcoming", DueAt: &tomorrow})
+			assert.NoError(t, err)
+			// act
+			overdue, err := service.ITaskService.GetOverdue(userId, nil, now)
+			assert.NoError(t, err)
+			upcoming, err := service.ITaskService.GetDueBetween(userId, nil, now, now.AddDate(0, 0, 2))
+			assert.NoError(t, err)
+			_, invalidErr := service.ITaskService.Create(userId, dtos.CreateTask{Title: "invalid", Description: "invalid", StartAt: &tomorrow, DueAt: &yesterday})
+			// assert
+			assert.Len(t, overdue, 1)
+			assert.Equal(t, overdueId, overdue[0].ID)
+			assert.Len(t, upcoming, 1)
+			assert.Equal(t, upcomingId, upcoming[0].ID)
+			assert.ErrorIs(t, invalidErr, services.ErrInvalidInput)
+		})
+		t.Run("Subtasks", func(t *testing.T) {
+			// arrange
+			userId := 1
+			parentId, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: "epic", Description: "epic"})
+			assert.NoError(t, err)
+			childId, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: "story", Description: "story", ParentTaskId: &parentId})
+			assert.NoError(t, err)
+			leafId, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: "step", Description: "step", ParentTaskId: &childId})
+			assert.NoError(t, err)
+			// act
+			cycleErr := service.ITaskService.SetParent(parentId, userId, &leafId)
+			refuseErr := service.ITaskService.Update(parentId, userId, dtos.UpdateTask{Title: "epic", Description: "epic", IsComplete: true})
+			leafErr := service.ITaskService.Update(leafId, userId, dtos.UpdateTask{Title: "step", Description: "step", IsComplete: true})
+			tree, treeErr := service.ITaskService.GetSubtree(parentId, userId)
+			completeErr := service.ITaskService.Update(parentId, userId, dtos.UpdateTask{Title: "epic", Description: "epic", IsComplete: true,
+				OnOpenSubtasks: services.OpenSubtasksComplete})
+			child, childErr := service.ITaskService.GetById(childId, userId)
+			// assert
+			assert.ErrorIs(t, cycleErr, services.ErrInvalidInput)
+			assert.ErrorIs(t, refuseErr, services.ErrConflict)
+			assert.NoError(t, leafErr)
+			assert.NoError(t, treeErr)
+			assert.Equal(t, 50, tree.Progress)
+			assert.Len(t, tree.Subtasks, 1)
+			assert.Equal(t, childId, tree.Subtasks[0].ID)
+			assert.Equal(t, 100, tree.Subtasks[0].Progress)
+			assert.NoError(t, completeErr)
+			assert.NoError(t, childErr)
+			assert.True(t, child.IsCompleted)
+		})
+		t.Run("ParentInOtherScope", func(t *testing.T) {
+			// arrange
+			userId, otherId := 1, 2
+			taskId, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: "chapter", Description: ""})
+			assert.NoError(t, err)
+			foreignId, err := service.ITaskService.Create(otherId, dtos.CreateTask{Title: "their book", Description: ""})
+			assert.NoError(t, err)
+			hiddenId, err := service.ITaskService.Create(otherId, dtos.CreateTask{Title: "their diary", Description: ""})
+			assert.NoError(t, err)
+			err = service.IShareService.ShareTask(foreignId, otherId, dtos.ShareForm{Username: "test", Role: models.ShareEditor})
+			assert.NoError(t, err)
+			// act
+			_, createErr := service.ITaskService.Create(userId, dtos.CreateTask{Title: "page", Description: "", ParentTaskId: &foreignId})
+			sharedErr := service.ITaskService.SetParent(taskId, userId, &foreignId)
+			hiddenErr := service.ITaskService.SetParent(taskId, userId, &hiddenId)
+			// assert
+			assert.ErrorIs(t, createErr, services.ErrInvalidInput)
+			assert.ErrorIs(t, sharedErr, services.ErrInvalidInput)
+			assert.ErrorIs(t, hiddenErr, services.ErrInvalidInput)
+		})
+		t.Run("Recurrence", func(t *testing.T) {
+			// arrange
+			userId := 1
+			dueAt := time.Date(2024, 10, 21, 9, 0, 0, 0, time.UTC)
+			recurrence := &dtos.Recurrence{Rule: "FREQ=WEEKLY;BYDAY=MO;COUNT=2"}
+			taskId, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: "weekly report", Description: "report", DueAt: &dueAt,
+				Recurrence: recurrence})
+			assert.NoError(t, err)
+			_, noDueErr := service.ITaskService.Create(userId, dtos.CreateTask{Title: "no due", Description: "no due", Recurrence: recurrence})
+			// act
+			err = service.ITaskService.Update(taskId, userId, dtos.UpdateTask{Title: "weekly report", Description: "report", IsComplete: true,
+				DueAt: &dueAt})
+			completed, getErr := service.ITaskService.GetById(taskId, userId)
+			page, listErr := service.ITaskService.Get(userId, dtos.TaskFilter{Title: "weekly report", Sort: "created", Order: "desc"})
+			// assert
+			assert.ErrorIs(t, noDueErr, services.ErrInvalidInput)
+			assert.NoError(t, err)
+			assert.NoError(t, getErr)
+			assert.Nil(t, completed.Recurrence)
+			assert.NoError(t, listErr)
+			assert.Equal(t, 2, page.Total)
+			next := page.Tasks[0]
+			assert.False(t, next.IsCompleted)
+			assert.True(t, dueAt.AddDate(0, 0, 7).Equal(*next.DueAt))
+			assert.Equal(t, &models.Recurrence{Rule: "FREQ=WEEKLY;BYDAY=MO;COUNT=1", TimeZone: "UTC"}, next.Recurrence)
+		})
+	})
+
+	t.Run("LabelService", func(t *testing.T) {
+		t.Run("CreateLabel", func(t *testing.T) {
+			// arrange
+			userId := 1
+			form := dtos.LabelForm{Name: "work", Color: "#ff0000"}
+			// act
+			id, err := service.ILabelService.Create(userId, form)
+			_, dupErr := service.ILabelService.Create(userId, form)
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, 1, id)
+			assert.ErrorIs(t, dupErr, services.ErrConflict)
+		})
+		t.Run("AttachLabel", func(t *testing.T) {
+			// arrange
+			userId, taskId, labelId := 1, 1, 1
+			// act
+			err := service.ILabelService.Attach(taskId, labelId, userId)
+			againErr := service.ILabelService.Attach(taskId, labelId, userId)
+			foreignErr := service.ILabelService.Attach(taskId, labelId, 2)
+			task, getErr := service.ITaskService.GetById(taskId, userId)
+			page, listErr := service.ITaskService.Get(userId, dtos.TaskFilter{Labels: []string{"work"}})
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, againErr)
+			assert.ErrorIs(t, foreignErr, services.ErrNotFound)
+			assert.NoError(t, getErr)
+			assert.Equal(t, []models.Label{{ID: 1, Name: "work", Color: "#ff0000"}}, task.Labels)
+			assert.NoError(t, listErr)
+			assert.Equal(t, 1, page.Total)
+			assert.Equal(t, taskId, page.Tasks[0].ID)
+		})
+		t.Run("DetachLabel", func(t *testing.T) {
+			// arrange
+			userId, taskId, labelId := 1, 1, 1
+			// act
+			err := service.ILabelService.Detach(taskId, labelId, userId)
+			task, getErr := service.ITaskService.GetById(taskId, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, getErr)
+			assert.Empty(t, task.Labels)
+		})
+		t.Run("DeleteLabel", func(t *testing.T) {
+			// arrange
+			userId, labelId := 1, 1
+			// act
+			err := service.ILabelService.Delete(labelId, userId)
+			missingErr := service.ILabelService.Delete(labelId, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, missingErr, services.ErrNotFound)
+		})
+	})
+
+	t.Run("ProjectService", func(t *testing.T) {
+		userId := 1
+		var projectId, taskId int
+		t.Run("CreateProject", func(t *testing.T) {
+			// arrange
+			project := dtos.CreateProject{Name: "release", Description: "release 1.0", Color: "#00ff00"}
+			// act
+			id, err := service.IProjectService.Create(userId, project)
+			_, emptyErr := service.IProjectService.Create(userId, dtos.CreateProject{Name: " "})
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, emptyErr, services.ErrInvalidInput)
+			projectId = id
+		})
+		t.Run("CreateTaskInProject", func(t *testing.T) {
+			// arrange
+			task := dtos.CreateTask{Title: "ship it", Description: "tag and publish", ProjectId: &projectId}
+			// act
+			id, err := service.ITaskService.Create(userId, task)
+			page, listErr := service.ITaskService.Get(userId, dtos.TaskFilter{ProjectId: &projectId})
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, listErr)
+			assert.Equal(t, 1, page.Total)
+			assert.Equal(t, id, page.Tasks[0].ID)
+			assert.Equal(t, &projectId, page.Tasks[0].ProjectId)
+			taskId = id
+		})
+		t.Run("MoveTaskToInbox", func(t *testing.T) {
+			// act
+			err := service.IProjectService.MoveTask(taskId, userId, nil)
+			task, getErr := service.ITaskService.GetById(taskId, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, getErr)
+			assert.Nil(t, task.ProjectId)
+		})
+		t.Run("EditorCannotMoveIntoOwnProject", func(t *testing.T) {
+			// arrange
+			otherId := 2
+			otherProjectId, err := service.IProjectService.Create(otherId, dtos.CreateProject{Name: "mine now"})
+			assert.NoError(t, err)
+			err = service.IShareService.ShareTask(taskId, userId, dtos.ShareForm{Username: "user", Role: models.ShareEditor})
+			assert.NoError(t, err)
+			// act
+			moveErr := service.IProjectService.MoveTask(taskId, otherId, &otherProjectId)
+			task, getErr := service.ITaskService.GetById(taskId, userId)
+			revokeErr := service.IShareService.RevokeTask(taskId, otherId, userId)
+			// assert
+			assert.ErrorIs(t, moveErr, services.ErrInvalidInput)
+			assert.NoError(t, getErr)
+			assert.Nil(t, task.ProjectId)
+			assert.NoError(t, revokeErr)
+		})
+		t.Run("ArchivedProjectRejectsTasks", func(t *testing.T) {
+			// arrange
+			update := dtos.UpdateProject{Name: "release", Archived: true}
+			// act
+			err := service.IProjectService.Update(projectId, userId, update)
+			moveErr := service.IProjectService.MoveTask(taskId, userId, &projectId)
+			active, listErr := service.IProjectService.Get(userId, false)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, moveErr, services.ErrInvalidInput)
+			assert.NoError(t, listErr)
+			assert.Empty(t, active)
+		})
+		t.Run("DeleteProjectInbox", func(t *testing.T) {
+			// arrange
+			sprintId, _ := service.IProjectService.Create(userId, dtos.CreateProject{Name: "sprint"})
+			guestTaskId, _ := service.ITaskService.Create(2, dtos.CreateTask{Title: "guest work", Description: ""})
+			db.Exec(`UPDATE tasks SET project_id = $1 WHERE id = $2`, sprintId, guestTaskId)
+			service.IWorkflowService.Replace(userId, &sprintId, dtos.Workflow{Statuses: []dtos.WorkflowStatus{{Name: "Todo"}, {Name: "Shipped", IsTerminal: true}}})
+			// act
+			err := service.IProjectService.Delete(sprintId, userId, services.ProjectDeleteInbox)
+			task, getErr := service.ITaskService.GetById(guestTaskId, 2)
+			guestWorkflow, workflowErr := service.IWorkflowService.Get(2, nil)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, getErr)
+			assert.NoError(t, workflowErr)
+			assert.Nil(t, task.ProjectId)
+			var statusIds []int
+			for _, status := range guestWorkflow.Statuses {
+				statusIds = append(statusIds, status.ID)
+			}
+			assert.Contains(t, statusIds, task.Status.ID)
+		})
+		t.Run("DeleteProjectCascade", func(t *testing.T) {
+			// arrange
+			assert.NoError(t, service.IProjectService.Update(projectId, userId, dtos.UpdateProject{Name: "release"}))
+			assert.NoError(t, service.IProjectService.MoveTask(taskId, userId, &projectId))
+			// act
+			err := service.IProjectService.Delete(projectId, userId, services.ProjectDeleteCascade)
+			_, getErr := service.ITaskService.GetById(taskId, userId)
+			trash, trashErr := service.ITaskService.GetTrash(userId, nil)
+			restoreErr := service.ITaskService.Restore(taskId, userId)
+			task, restoredErr := service.ITaskService.GetById(taskId, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, getErr, services.ErrNotFound)
+			assert.NoError(t, trashErr)
+			assert.Equal(t, taskId, trash[0].ID)
+			assert.NoError(t, restoreErr)
+			assert.NoError(t, restoredErr)
+			assert.Nil(t, task.ProjectId)
+			assert.NotNil(t, task.Status)
+		})
+		t.Run("DeleteProjectHistory", func(t *testing.T) {
+			// arrange
+			launchId, _ := service.IProjectService.Create(userId, dtos.CreateProject{Name: "launch day"})
+			launchTaskId, _ := service.ITaskService.Create(userId, dtos.CreateTask{Title: "countdown", Description: "", ProjectId: &launchId})
+			service.IWorkflowService.Replace(userId, &launchId, dtos.Workflow{Statuses: []dtos.WorkflowStatus{{Name: "Fueling"}, {Name: "Lifted off", IsTerminal: true}}})
+			// act
+			err := service.IProjectService.Delete(launchId, userId, services.ProjectDeleteCascade)
+			restoreErr := service.ITaskService.Restore(launchTaskId, userId)
+			history, historyErr := service.IHistoryService.GetTaskHistory(launchTaskId, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, restoreErr)
+			assert.NoError(t, historyErr)
+			assert.Len(t, history, 6)
+			assert.Equal(t, models.TaskCreated, history[0].Action)
+			assert.Equal(t, models.TaskUpdated, history[1].Action)
+			assert.JSONEq(t, `"Fueling"`, string(history[1].Changes["status"].To))
+			assert.Equal(t, models.TaskUpdated, history[2].Action)
+			assert.JSONEq(t, `"Fueling"`, string(history[2].Changes["status"].From))
+			assert.Equal(t, models.TaskDeleted, history[3].Action)
+			assert.Equal(t, models.TaskUpdated, history[4].Action)
+			assert.JSONEq(t, strconv.Itoa(launchId), string(history[4].Changes["project_id"].From))
+			assert.JSONEq(t, `null`, string(history[4].Changes["project_id"].To))
+			assert.Equal(t, models.TaskRestored, history[5].Action)
+		})
+	})
+
+	t.Run("DependencyService", func(t *testing.T) {
+		userId := 1
+		ids := make([]int, 3)
+		for i, title := range []string{"deploy", "test", "build"} {
+			id, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: title, Description: title})
+			assert.NoError(t, err)
+			ids[i] = id
+		}
+		deploy, test, build := ids[0], ids[1], ids[2]
+		t.Run("AddBlocker", func(t *testing.T) {
+			// act
+			err := service.IDependencyService.AddBlocker(deploy, test, userId)
+			chainErr := service.IDependencyService.AddBlocker(test, build, userId)
+			cycleErr := service.IDependencyService.AddBlocker(build, deploy, userId)
+			blockers, getErr := service.IDependencyService.GetBlockers(deploy, userId)
+			dependents, depErr := service.IDependencyService.GetDependents(build, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, chainErr)
+			assert.ErrorIs(t, cycleErr, services.ErrInvalidInput)
+			assert.NoError(t, getErr)
+			assert.Equal(t, test, blockers[0].ID)
+			assert.NoError(t, depErr)
+			assert.Equal(t, test, dependents[0].ID)
+		})
+		t.Run("GetAvailable", func(t *testing.T) {
+			// act
+			available, err := service.IDependencyService.GetAvailable(userId, nil)
+			availableIds := make([]int, 0, len(available))
+			for _, task := range available {
+				availableIds = append(availableIds, task.ID)
+			}
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, build, availableIds[0])
+			assert.NotContains(t, availableIds, test)
+			assert.NotContains(t, availableIds, deploy)
+		})
+		t.Run("CompleteBlockedTask", func(t *testing.T) {
+			// arrange
+			update := dtos.UpdateTask{Title: "deploy", Description: "deploy", IsComplete: true}
+			// act
+			blockedErr := service.ITaskService.Update(deploy, userId, update)
+			update.Force = true
+			forcedErr := service.ITaskService.Update(deploy, userId, update)
+			// assert
+			assert.ErrorIs(t, blockedErr, services.ErrConflict)
+			assert.NoError(t, forcedErr)
+		})
+		t.Run("RemoveBlocker", func(t *testing.T) {
+			// act
+			err := service.IDependencyService.RemoveBlocker(deploy, test, userId)
+			blockers, getErr := service.IDependencyService.GetBlockers(deploy, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, getErr)
+			assert.Equal(t, []models.Task{}, blockers)
+		})
+		t.Run("NoDependents", func(t *testing.T) {
+			// act
+			dependents, err := service.IDependencyService.GetDependents(deploy, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, []models.Task{}, dependents)
+		})
+	})
+	t.Run("WorkflowService", func(t *testing.T) {
+		userId := 1
+		t.Run("DefaultWorkflow", func(t *testing.T) {
+			// act
+			workflow, err := service.IWorkflowService.Get(userId, nil)
+			names := make([]string, 0, len(workflow.Statuses))
+			for _, status := range workflow.Statuses {
+				names = append(names, status.Name)
+			}
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"Backlog", "In Progress", "Review", "Done"}, names)
+			assert.True(t, workflow.Statuses[3].IsTerminal)
+		})
+		t.Run("PriorityAndInitialStatus", func(t *testing.T) {
+			// act
+			taskId, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: "triage", Description: "triage", Priority: "urgent"})
+			task, getErr := service.ITaskService.GetById(taskId, userId)
+			_, badErr := service.ITaskService.Create(userId, dtos.CreateTask{Title: "bad", Description: "bad", Priority: "asap"})
+			urgent, listErr := service.ITaskService.Get(userId, dtos.TaskFilter{Priority: "urgent"})
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, getErr)
+			assert.Equal(t, models.PriorityUrgent, task.Priority)
+			assert.Equal(t, "Backlog", task.Status.Name)
+			assert.ErrorIs(t, badErr, services.ErrInvalidInput)
+			assert.NoError(t, listErr)
+			assert.Equal(t, 1, urgent.Total)
+		})
+		t.Run("Transitions", func(t *testing.T) {
+			// arrange
+			workflow := dtos.Workflow{
+				Statuses: []dtos.WorkflowStatus{{Name: "Todo"}, {Name: "Doing"}, {Name: "Done", IsTerminal: true}},
+				Transitions: []dtos.WorkflowTransition{
+					{From: "Todo", To: "Doing"},
+					{From: "Doing", To: "Done"},
+					{From: "Done", To: "Doing"},
+				},
+			}
+			projectId, err := service.IProjectService.Create(userId, dtos.CreateProject{Name: "kanban"})
+			assert.NoError(t, err)
+			assert.NoError(t, service.IWorkflowService.Replace(userId, &projectId, workflow))
+			resolved, err := service.IWorkflowService.Get(userId, &projectId)
+			assert.NoError(t, err)
+			todo, doing := resolved.Statuses[0].ID, resolved.Statuses[1].ID
+			taskId, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: "card", Description: "card", ProjectId: &projectId})
+			assert.NoError(t, err)
+			// act
+			skipErr := service.ITaskService.Update(taskId, userId, dtos.UpdateTask{Title: "card", Description: "card", IsComplete: true})
+			moveErr := service.ITaskService.Update(taskId, userId, dtos.UpdateTask{Title: "card", Description: "card", StatusId: &doing})
+			completeErr := service.ITaskService.Update(taskId, userId, dtos.UpdateTask{Title: "card", Description: "card", IsComplete: true})
+			task, getErr := service.ITaskService.GetById(taskId, userId)
+			backErr := service.ITaskService.Update(taskId, userId, dtos.UpdateTask{Title: "card", Description: "card", StatusId: &todo})
+			// assert
+			assert.ErrorIs(t, skipErr, services.ErrConflict)
+			assert.NoError(t, moveErr)
+			assert.NoError(t, completeErr)
+			assert.NoError(t, getErr)
+			assert.True(t, task.IsCompleted)
+			assert.Equal(t, "Done", task.Status.Name)
+			assert.ErrorIs(t, backErr, services.ErrConflict)
+		})
+		t.Run("InvalidWorkflow", func(t *testing.T) {
+			// arrange
+			workflow := dtos.Workflow{Statuses: []dtos.WorkflowStatus{{Name: "Open"}}}
+			// act
+			err := service.IWorkflowService.Replace(userId, nil, workflow)
+			// assert
+			assert.ErrorIs(t, err, services.ErrInvalidInput)
+		})
+	})
+	t.Run("BoardService", func(t *testing.T) {
+		userId := 1
+		ids := make([]int, 3)
+		for i, title := range []string{"first", "second", "third"} {
+			id, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: title, Description: title})
+			assert.NoError(t, err)
+			ids[i] = id
+		}
+		first, second, third := ids[0], ids[1], ids[2]
+		columns, err := service.IBoardService.GetColumns(userId)
+		assert.NoError(t, err)
+		open, done := columns[0], columns[1]
+		laneOrder := func(board *models.Board, column int) []int {
+			var order []int
+			for _, lane := range board.Columns {
+				if lane.ID != column {
+					continue
+				}
+				for _, task := range lane.Tasks {
+					if task.ID == first || task.ID == second || task.ID == third {
+						order = append(order, task.ID)
+					}
+				}
+			}
+			return order
+		}
+		t.Run("DefaultColumns", func(t *testing.T) {
+			// assert
+			assert.Equal(t, []string{"Open", "Done"}, []string{open.Name, done.Name})
+			assert.False(t, open.IsDone)
+			assert.True(t, done.IsDone)
+		})
+		t.Run("Reorder", func(t *testing.T) {
+			// act
+			topErr := service.IBoardService.MoveCard(third, userId, dtos.MoveCard{ColumnId: open.ID})
+			afterErr := service.IBoardService.MoveCard(first, userId, dtos.MoveCard{ColumnId: open.ID, AfterId: &second})
+			board, err := service.IBoardService.GetBoard(userId, nil, nil)
+			// assert
+			assert.NoError(t, topErr)
+			assert.NoError(t, afterErr)
+			assert.NoError(t, err)
+			assert.Equal(t, []int{third, second, first}, laneOrder(board, open.ID))
+			assert.Equal(t, third, board.Columns[0].Tasks[0].ID)
+		})
+		t.Run("MoveToDone", func(t *testing.T) {
+			// act
+			err := service.IBoardService.MoveCard(second, userId, dtos.MoveCard{ColumnId: done.ID})
+			task, getErr := service.ITaskService.GetById(second, userId)
+			board, boardErr := service.IBoardService.GetBoard(userId, nil, nil)
+			wrongColumnErr := service.IBoardService.MoveCard(third, userId, dtos.MoveCard{ColumnId: open.ID, AfterId: &second})
+			history, historyErr := service.IHistoryService.GetTaskHistory(second, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, getErr)
+			assert.True(t, task.IsCompleted)
+			assert.NoError(t, historyErr)
+			assert.JSONEq(t, `true`, string(history[len(history)-1].Changes["is_completed"].To))
+			assert.NoError(t, boardErr)
+			assert.Equal(t, []int{second}, laneOrder(board, done.ID))
+			assert.ErrorIs(t, wrongColumnErr, services.ErrInvalidInput)
+		})
+		t.Run("KeepDoneColumn", func(t *testing.T) {
+			// act
+			err := service.IBoardService.DeleteColumn(done.ID, userId)
+			// assert
+			assert.ErrorIs(t, err, services.ErrConflict)
+		})
+	})
+	t.Run("CommentService", func(t *testing.T) {
+		userId, taskId := 1, 1
+		var commentId int
+		t.Run("CreateComment", func(t *testing.T) {
+			// act
+			id, err := service.ICommentService.Create(taskId, userId, dtos.CommentForm{Body: "first *draft*"})
+			_, emptyErr := service.ICommentService.Create(taskId, userId, dtos.CommentForm{Body: "  "})
+			task, getErr := service.ITaskService.GetById(taskId, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, emptyErr, services.ErrInvalidInput)
+			assert.NoError(t, getErr)
+			assert.Equal(t, 1, task.CommentCount)
+			commentId = id
+		})
+		t.Run("LongComment", func(t *testing.T) {
+			// act
+			id, err := service.ICommentService.Create(taskId, userId, dtos.CommentForm{Body: strings.Repeat("ж", 10000)})
+			_, longErr := service.ICommentService.Create(taskId, userId, dtos.CommentForm{Body: strings.Repeat("ж", 10001)})
+			deleteErr := service.ICommentService.Delete(taskId, id, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, longErr, services.ErrInvalidInput)
+			assert.NoError(t, deleteErr)
+		})
+		t.Run("EditComment", func(t *testing.T) {
+			// act
+			err := service.ICommentService.Update(taskId, commentId, userId, dtos.CommentForm{Body: "second draft"})
+			againErr := service.ICommentService.Update(taskId, commentId, userId, dtos.CommentForm{Body: "final"})
+			comments, getErr := service.ICommentService.Get(taskId, userId)
+			versions, versionsErr := service.ICommentService.GetVersions(taskId, commentId, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, againErr)
+			assert.NoError(t, getErr)
+			assert.Equal(t, "final", comments[0].Body)
+			assert.Equal(t, "test", comments[0].Author)
+			assert.NotNil(t, comments[0].EditedAt)
+			assert.NoError(t, versionsErr)
+			assert.Equal(t, []string{"first *draft*", "second draft"}, []string{versions[0].Body, versions[1].Body})
+		})
+		t.Run("DeleteComment", func(t *testing.T) {
+			// act
+			err := service.ICommentService.Delete(taskId, commentId, userId)
+			againErr := service.ICommentService.Delete(taskId, commentId, userId)
+			comments, getErr := service.ICommentService.Get(taskId, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, againErr, services.ErrNotFound)
+			assert.NoError(t, getErr)
+			assert.Empty(t, comments)
+		})
+	})
+	t.Run("AttachmentService", func(t *testing.T) {
+		userId := 1
+		taskId, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: "crash report", Description: "with logs"})
+		assert.NoError(t, err)
+		var attachmentId int
+		t.Run("CreateAttachment", func(t *testing.T) {
+			// arrange
+			log := "panic: runtime error: index out of range"
+			// act
+			id, err := service.IAttachmentService.Create(taskId, userId, dtos.Upload{FileName: `C:\logs\crash.log`, Size: int64(len(log)), Content: strings.NewReader(log)})
+			_, largeErr := service.IAttachmentService.Create(taskId, userId, dtos.Upload{FileName: "big.log", Size: 2048, Content: strings.NewReader(strings.Repeat("x", 2048))})
+			_, typeErr := service.IAttachmentService.Create(taskId, userId, dtos.Upload{FileName: "page.html", Size: 15, Content: strings.NewReader("<html></html>\n\n")})
+			_, missingErr := service.IAttachmentService.Create(100, userId, dtos.Upload{FileName: "crash.log", Size: int64(len(log)), Content: strings.NewReader(log)})
+			attachments, getErr := service.IAttachmentService.Get(taskId, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, largeErr, services.ErrInvalidInput)
+			assert.ErrorIs(t, typeErr, services.ErrInvalidInput)
+			assert.ErrorIs(t, missingErr, services.ErrNotFound)
+			assert.NoError(t, getErr)
+			assert.Len(t, attachments, 1)
+			assert.Equal(t, "crash.log", attachments[0].FileName)
+			assert.Equal(t, "text/plain; charset=utf-8", attachments[0].ContentType)
+			assert.Equal(t, int64(len(log)), attachments[0].Size)
+			attachmentId = id
+		})
+		t.Run("OpenAttachment", func(t *testing.T) {
+			// act
+			attachment, content, err := service.IAttachmentService.Open(taskId, attachmentId, userId)
+			var actual []byte
+			if err == nil {
+				actual, _ = io.ReadAll(content)
+				content.Close()
+			}
+			_, _, otherErr := service.IAttachmentService.Open(taskId, attachmentId, 2)
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, "crash.log", attachment.FileName)
+			assert.Equal(t, "panic: runtime error: index out of range", string(actual))
+			assert.ErrorIs(t, otherErr, services.ErrNotFound)
+		})
+		t.Run("DeleteAttachment", func(t *testing.T) {
+			// arrange
+			id, err := service.IAttachmentService.Create(taskId, userId, dtos.Upload{FileName: "second.log", Size: 2, Content: strings.NewReader("ok")})
+			assert.NoError(t, err)
+			// act
+			deleteErr := service.IAttachmentService.Delete(taskId, id, userId)
+			againErr := service.IAttachmentService.Delete(taskId, id, userId)
+			_, _, openErr := service.IAttachmentService.Open(taskId, id, userId)
+			// assert
+			assert.NoError(t, deleteErr)
+			assert.ErrorIs(t, againErr, services.ErrNotFound)
+			assert.ErrorIs(t, openErr, services.ErrNotFound)
+		})
+		t.Run("PurgeTaskRemovesBlobs", func(t *testing.T) {
+			// arrange
+			subtaskId, err := service.ITaskService.Create(userId, dtos.CreateTask{Title: "stack trace", Description: "subtask", ParentTaskId: &taskId})
+			assert.NoError(t, err)
+			_, err = service.IAttachmentService.Create(subtaskId, userId, dtos.Upload{FileName: "trace.txt", Size: 5, Content: strings.NewReader("trace")})
+			assert.NoError(t, err)
+			var keys []string
+			rows, err := db.Query(`SELECT storage_key FROM attachments WHERE task_id IN ($1, $2)`, taskId, subtaskId)
+			assert.NoError(t, err)
+			for rows.Next() {
+				var key string
+				rows.Scan(&key)
+				keys = append(keys, key)
+			}
+			rows.Close()
+			// act
+			err = service.ITaskService.Delete(taskId, userId)
+			_, keptErr := blobStore.Get(context.Background(), keys[0])
+			purgeErr := service.ITaskService.Purge(taskId, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, keptErr)
+			assert.NoError(t, purgeErr)
+			assert.Len(t, keys, 2)
+			for _, key := range keys {
+				_, getErr := blobStore.Get(context.Background(), key)
+				assert.ErrorIs(t, getErr, storage.ErrNotExist)
+			}
+		})
+	})
+	t.Run("ShareService", func(t *testing.T) {
+		ownerId, otherId := 1, 2
+		taskId, err := service.ITaskService.Create(ownerId, dtos.CreateTask{Title: "release notes", Description: "draft"})
+		assert.NoError(t, err)
+		update := dtos.UpdateTask{Title: "release notes", Description: "reviewed"}
+		t.Run("ShareTaskAsViewer", func(t *testing.T) {
+			// act
+			hiddenErr := service.ITaskService.Update(taskId, otherId, update)
+			err := service.IShareService.ShareTask(taskId, ownerId, dtos.ShareForm{Username: "user", Role: models.ShareViewer})
+			task, getErr := service.ITaskService.GetById(taskId, otherId)
+			updateErr := service.ITaskService.Update(taskId, otherId, update)
+			_, listErr := service.IShareService.GetTaskShares(taskId, otherId)
+			reshareErr := service.IShareService.ShareTask(taskId, otherId, dtos.ShareForm{Username: "test", Role: models.ShareEditor})
+			// assert
+			assert.ErrorIs(t, hiddenErr, services.ErrNotFound)
+			assert.NoError(t, err)
+			assert.NoError(t, getErr)
+			assert.Equal(t, "draft", task.Description)
+			assert.ErrorIs(t, updateErr, services.ErrForbidden)
+			assert.ErrorIs(t, listErr, services.ErrForbidden)
+			assert.ErrorIs(t, reshareErr, services.ErrForbidden)
+		})
+		t.Run("ShareTaskAsEditor", func(t *testing.T) {
+			// act
+			err := service.IShareService.ShareTask(taskId, ownerId, dtos.ShareForm{Username: "user", Role: models.ShareEditor})
+			updateErr := service.ITaskService.Update(taskId, otherId, update)
+			_, commentErr := service.ICommentService.Create(taskId, otherId, dtos.CommentForm{Body: "looks good"})
+			deleteErr := service.ITaskService.Delete(taskId, otherId)
+			shares, sharesErr := service.IShareService.GetTaskShares(taskId, ownerId)
+			shared, sharedErr := service.IShareService.GetSharedWithMe(otherId)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, updateErr)
+			assert.NoError(t, commentErr)
+			assert.ErrorIs(t, deleteErr, services.ErrForbidden)
+			assert.NoError(t, sharesErr)
+			assert.Equal(t, []models.Share{{UserId: otherId, Username: "user", Role: models.ShareEditor, CreateAt: shares[0].CreateAt}}, shares)
+			assert.NoError(t, sharedErr)
+			assert.Len(t, shared, 1)
+			assert.Equal(t, taskId, shared[0].ID)
+			assert.Equal(t, "reviewed", shared[0].Description)
+			assert.Equal(t, "test", shared[0].Owner)
+			assert.Equal(t, models.ShareEditor, shared[0].Role)
+		})
+		t.Run("RevokeTaskShare", func(t *testing.T) {
+			// act
+			err := service.IShareService.RevokeTask(taskId, otherId, ownerId)
+			_, getErr := service.ITaskService.GetById(taskId, otherId)
+			shared, sharedErr := service.IShareService.GetSharedWithMe(otherId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, getErr, services.ErrNotFound)
+			assert.NoError(t, sharedErr)
+			assert.Equal(t, []models.SharedTask{}, shared)
+		})
+		t.Run("ShareProject", func(t *testing.T) {
+			// arrange
+			projectId, err := service.IProjectService.Create(ownerId, dtos.CreateProject{Name: "launch"})
+			assert.NoError(t, err)
+			projectTaskId, err := service.ITaskService.Create(ownerId, dtos.CreateTask{Title: "press kit", Description: "logos", ProjectId: &projectId})
+			assert.NoError(t, err)
+			// act
+			err = service.IShareService.ShareProject(projectId, ownerId, dtos.ShareForm{Username: "user", Role: models.ShareViewer})
+			invalidErr := service.IShareService.ShareProject(projectId, ownerId, dtos.ShareForm{Username: "user", Role: "admin"})
+			task, getErr := service.ITaskService.GetById(projectTaskId, otherId)
+			shared, sharedErr := service.IShareService.GetSharedWithMe(otherId)
+			leaveErr := service.IShareService.RevokeProject(projectId, otherId, otherId)
+			_, revokedErr := service.ITaskService.GetById(projectTaskId, otherId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, invalidErr, services.ErrInvalidInput)
+			assert.NoError(t, getErr)
+			assert.Equal(t, "press kit", task.Title)
+			assert.NoError(t, sharedErr)
+			assert.Len(t, shared, 1)
+			assert.Equal(t, models.ShareViewer, shared[0].Role)
+			assert.NoError(t, leaveErr)
+			assert.ErrorIs(t, revokedErr, services.ErrNotFound)
+		})
+		t.Run("ProjectShareRecipient", func(t *testing.T) {
+			// arrange
+			projectId, err := service.IProjectService.Create(ownerId, dtos.CreateProject{Name: "conference"})
+			assert.NoError(t, err)
+			projectTaskId, err := service.ITaskService.Create(ownerId, dtos.CreateTask{Title: "book venue", Description: "", ProjectId: &projectId})
+			assert.NoError(t, err)
+			err = service.IShareService.ShareProject(projectId, ownerId, dtos.ShareForm{Username: "user", Role: models.ShareEditor})
+			assert.NoError(t, err)
+			// act
+			project, getErr := service.IProjectService.GetById(projectId, otherId)
+			page, listErr := service.ITaskService.Get(otherId, dtos.TaskFilter{ProjectId: &projectId})
+			createdId, createErr := service.ITaskService.Create(otherId, dtos.CreateTask{Title: "print badges", Description: "", ProjectId: &projectId})
+			created, createdErr := service.ITaskService.GetById(createdId, ownerId)
+			updateErr := service.IProjectService.Update(projectId, otherId, dtos.UpdateProject{Name: "taken over"})
+			deleteErr := service.IProjectService.Delete(projectId, otherId, services.ProjectDeleteInbox)
+			err = service.IShareService.ShareProject(projectId, ownerId, dtos.ShareForm{Username: "user", Role: models.ShareViewer})
+			_, viewerErr := service.ITaskService.Create(otherId, dtos.CreateTask{Title: "sneak in", Description: "", ProjectId: &projectId})
+			// assert
+			assert.NoError(t, getErr)
+			assert.Equal(t, "conference", project.Name)
+			assert.NoError(t, listErr)
+			assert.Len(t, page.Tasks, 1)
+			assert.Equal(t, projectTaskId, page.Tasks[0].ID)
+			assert.NoError(t, createErr)
+			assert.NoError(t, createdErr)
+			assert.Equal(t, "print badges", created.Title)
+			assert.ErrorIs(t, updateErr, services.ErrNotFound)
+			assert.ErrorIs(t, deleteErr, services.ErrNotFound)
+			assert.NoError(t, err)
+			assert.ErrorIs(t, viewerErr, services.ErrForbidden)
+		})
+	})
+	t.Run("WorkspaceService", func(t *testing.T) {
+		ownerId, otherId := 1, 2
+		var workspaceId, invitationId, taskId int
+		t.Run("CreateWorkspace", func(t *testing.T) {
+			// act
+			id, err := service.IWorkspaceService.Create(ownerId, dtos.WorkspaceForm{Name: "platform team"})
+			_, emptyErr := service.IWorkspaceService.Create(ownerId, dtos.WorkspaceForm{Name: " "})
+			workspaces, getErr := service.IWorkspaceService.Get(ownerId)
+			_, outsiderErr := service.IWorkspaceService.GetMembers(id, otherId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, emptyErr, services.ErrInvalidInput)
+			assert.NoError(t, getErr)
+			assert.Equal(t, []models.Workspace{{ID: id, Name: "platform team", Role: models.WorkspaceOwner, CreateAt: workspaces[0].CreateAt}}, workspaces)
+			assert.ErrorIs(t, outsiderErr, services.ErrNotFound)
+			workspaceId = id
+		})
+		t.Run("WorkspaceTasks", func(t *testing.T) {
+			// act
+			id, err := service.ITaskService.Create(ownerId, dtos.CreateTask{Title: "on-call rota", Description: "weekly", WorkspaceId: &workspaceId})
+			_, outsiderErr := service.ITaskService.Create(otherId, dtos.CreateTask{Title: "intruder", Description: "", WorkspaceId: &workspaceId})
+			workspacePage, workspaceErr := service.ITaskService.Get(ownerId, dtos.TaskFilter{WorkspaceId: &workspaceId})
+			personalPage, personalErr := service.ITaskService.Get(ownerId, dtos.TaskFilter{Title: "on-call"})
+			_, hiddenErr := service.ITaskService.GetById(id, otherId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, outsiderErr, services.ErrNotFound)
+			assert.NoError(t, workspaceErr)
+			assert.Equal(t, 1, workspacePage.Total)
+			assert.Equal(t, &workspaceId, workspacePage.Tasks[0].WorkspaceId)
+			assert.NoError(t, personalErr)
+			assert.Equal(t, 0, personalPage.Total)
+			assert.ErrorIs(t, hiddenErr, services.ErrNotFound)
+			taskId = id
+		})
+		t.Run("Invite", func(t *testing.T) {
+			// act
+			id, err := service.IWorkspaceService.Invite(workspaceId, ownerId, dtos.InvitationForm{Username: "user", Role: models.WorkspaceGuest})
+			_, againErr := service.IWorkspaceService.Invite(workspaceId, ownerId, dtos.InvitationForm{Username: "user", Role: models.WorkspaceMember})
+			_, unknownErr := service.IWorkspaceService.Invite(workspaceId, ownerId, dtos.InvitationForm{Username: "nobody", Role: models.WorkspaceMember})
+			invitations, getErr := service.IWorkspaceService.GetInvitations(otherId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, againErr, services.ErrConflict)
+			assert.ErrorIs(t, unknownErr, services.ErrInvalidInput)
+			assert.NoError(t, getErr)
+			assert.Len(t, invitations, 1)
+			assert.Equal(t, "platform team", invitations[0].Workspace)
+			assert.Equal(t, "test", *invitations[0].InvitedBy)
+			invitationId = id
+		})
+		t.Run("AcceptInvitation", func(t *testing.T) {
+			// act
+			err := service.IWorkspaceService.AcceptInvitation(invitationId, otherId)
+			againErr := service.IWorkspaceService.DeclineInvitation(invitationId, otherId)
+			role, roleErr := service.IWorkspaceService.Role(workspaceId, otherId)
+			task, getErr := service.ITaskService.GetById(taskId, otherId)
+			updateErr := service.ITaskService.Update(taskId, otherId, dtos.UpdateTask{Title: "on-call rota", Description: "daily"})
+			_, createErr := service.ITaskService.Create(otherId, dtos.CreateTask{Title: "guest task", Description: "", WorkspaceId: &workspaceId})
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, againErr, services.ErrNotFound)
+			assert.NoError(t, roleErr)
+			assert.Equal(t, models.WorkspaceGuest, role)
+			assert.NoError(t, getErr)
+			assert.Equal(t, "weekly", task.Description)
+			assert.ErrorIs(t, updateErr, services.ErrForbidden)
+			assert.ErrorIs(t, createErr, services.ErrForbidden)
+		})
+		t.Run("SetMemberRole", func(t *testing.T) {
+			// act
+			err := service.IWorkspaceService.SetMemberRole(workspaceId, otherId, ownerId, models.WorkspaceMember)
+			updateErr := service.ITaskService.Update(taskId, otherId, dtos.UpdateTask{Title: "on-call rota", Description: "daily"})
+			deleteErr := service.ITaskService.Delete(taskId, otherId)
+			selfErr := service.IWorkspaceService.SetMemberRole(workspaceId, otherId, otherId, models.WorkspaceOwner)
+			lastOwnerErr := service.IWorkspaceService.SetMemberRole(workspaceId, ownerId, ownerId, models.WorkspaceAdmin)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, updateErr)
+			assert.ErrorIs(t, deleteErr, services.ErrForbidden)
+			assert.ErrorIs(t, selfErr, services.ErrForbidden)
+			assert.ErrorIs(t, lastOwnerErr, services.ErrConflict)
+		})
+		t.Run("MemberWorksOnTasks", func(t *testing.T) {
+			// arrange
+			labelId, _ := service.ILabelService.Create(otherId, dtos.LabelForm{Name: "rota", Color: "#00ff00"})
+			columns, _ := service.IBoardService.GetColumns(otherId)
+			// act
+			board, boardErr := service.IBoardService.GetBoard(otherId, &workspaceId, nil)
+			moveErr := service.IBoardService.MoveCard(taskId, otherId, dtos.MoveCard{ColumnId: columns[0].ID})
+			available, availableErr := service.IDependencyService.GetAvailable(otherId, &workspaceId)
+			attachErr := service.ILabelService.Attach(taskId, labelId, otherId)
+			projectErr := service.IProjectService.MoveTask(taskId, otherId, nil)
+			// assert
+			assert.NoError(t, boardErr)
+			assert.Equal(t, taskId, board.Columns[0].Tasks[0].ID)
+			assert.NoError(t, moveErr)
+			assert.NoError(t, availableErr)
+			assert.Len(t, available, 1)
+			assert.NoError(t, attachErr)
+			assert.NoError(t, projectErr)
+		})
+		t.Run("RemoveMember", func(t *testing.T) {
+			// act
+			lastOwnerErr := service.IWorkspaceService.RemoveMember(workspaceId, ownerId, ownerId)
+			err := service.IWorkspaceService.RemoveMember(workspaceId, otherId, otherId)
+			_, getErr := service.ITaskService.GetById(taskId, otherId)
+			_, listErr := service.ITaskService.Get(otherId, dtos.TaskFilter{WorkspaceId: &workspaceId})
+			members, membersErr := service.IWorkspaceService.GetMembers(workspaceId, ownerId)
+			// assert
+			assert.ErrorIs(t, lastOwnerErr, services.ErrConflict)
+			assert.NoError(t, err)
+			assert.ErrorIs(t, getErr, services.ErrNotFound)
+			assert.ErrorIs(t, listErr, services.ErrNotFound)
+			assert.NoError(t, membersErr)
+			assert.Len(t, members, 1)
+		})
+		t.Run("DeleteWorkspace", func(t *testing.T) {
+			// act
+			err := service.IWorkspaceService.Delete(workspaceId, ownerId)
+			_, getErr := service.ITaskService.GetById(taskId, ownerId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, getErr, services.ErrNotFound)
+		})
+	})
+	t.Run("AssigneeService", func(t *testing.T) {
+		ownerId, otherId := 1, 2
+		var workspaceId, taskId int
+		t.Run("Assign", func(t *testing.T) {
+			// arrange
+			workspaceId, _ = service.IWorkspaceService.Create(ownerId, dtos.WorkspaceForm{Name: "support"})
+			invitationId, _ := service.IWorkspaceService.Invite(workspaceId, ownerId, dtos.InvitationForm{Username: "user", Role: models.WorkspaceMember})
+			service.IWorkspaceService.AcceptInvitation(invitationId, otherId)
+			taskId, _ = service.ITaskService.Create(ownerId, dtos.CreateTask{Title: "answer tickets", Description: "", WorkspaceId: &workspaceId})
+			// act
+			err := service.IAssigneeService.Assign(taskId, otherId, ownerId)
+			againErr := service.IAssigneeService.Assign(taskId, otherId, ownerId)
+			unknownErr := service.IAssigneeService.Assign(taskId, 1000, ownerId)
+			assignees, getErr := service.IAssigneeService.Get(taskId, otherId)
+			task, taskErr := service.ITaskService.GetById(taskId, ownerId)
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, againErr)
+			assert.ErrorIs(t, unknownErr, services.ErrInvalidInput)
+			assert.NoError(t, getErr)
+			assert.Len(t, assignees, 1)
+			assert.Equal(t, "user", assignees[0].Username)
+			assert.Equal(t, "test", *assignees[0].AssignedBy)
+			assert.NoError(t, taskErr)
+			assert.Equal(t, assignees, task.Assignees)
+		})
+		t.Run("FilterAssignedToMe", func(t *testing.T) {
+			// arrange
+			service.ITaskService.Create(ownerId, dtos.CreateTask{Title: "triage backlog", Description: "", WorkspaceId: &workspaceId})
+			// act
+			assigned, assignedErr := service.ITaskService.Get(otherId, dtos.TaskFilter{WorkspaceId: &workspaceId, AssignedToMe: true})
+			created, createdErr := service.ITaskService.Get(otherId, dtos.TaskFilter{WorkspaceId: &workspaceId, CreatedByMe: true})
+			unassigned, unassignedErr := service.ITaskService.Get(otherId, dtos.TaskFilter{WorkspaceId: &workspaceId, Unassigned: true})
+			_, bothErr := service.ITaskService.Get(otherId, dtos.TaskFilter{WorkspaceId: &workspaceId, AssignedToMe: true, Unassigned: true})
+			everywhere, everywhereErr := service.ITaskService.Get(otherId, dtos.TaskFilter{AssignedToMe: true})
+			// assert
+			assert.NoError(t, assignedErr)
+			assert.Equal(t, 1, assigned.Total)
+			assert.Equal(t, taskId, assigned.Tasks[0].ID)
+			assert.NoError(t, everywhereErr)
+			assert.Equal(t, 1, everywhere.Total)
+			assert.Equal(t, taskId, everywhere.Tasks[0].ID)
+			assert.NoError(t, createdErr)
+			assert.Equal(t, 0, created.Total)
+			assert.NoError(t, unassignedErr)
+			assert.Equal(t, 1, unassigned.Total)
+			assert.Equal(t, "triage backlog", unassigned.Tasks[0].Title)
+			assert.ErrorIs(t, bothErr, services.ErrInvalidInput)
+		})
+		t.Run("Unassign", func(t *testing.T) {
+			// act
+			err := service.IAssigneeService.Unassign(taskId, otherId, otherId)
+			againErr := service.IAssigneeService.Unassign(taskId, otherId, ownerId)
+			changes, changesErr := service.IAssigneeService.GetChanges(taskId, ownerId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, againErr, services.ErrNotFound)
+			assert.NoError(t, changesErr)
+			assert.Len(t, changes, 2)
+			assert.Equal(t, models.AssignmentAssigned, changes[0].Action)
+			assert.Equal(t, "test", *changes[0].ChangedBy)
+			assert.Equal(t, models.AssignmentUnassigned, changes[1].Action)
+			assert.Equal(t, "user", *changes[1].ChangedBy)
+		})
+	})
+	t.Run("Search", func(t *testing.T) {
+		userId, otherId := 1, 2
+		// arrange
+		zeppelinId, _ := service.ITaskService.Create(userId, dtos.CreateTask{Title: "Zeppelin hangar checklist", Description: "Inflate the <ballast> before the voyage"})
+		voyagesId, _ := service.ITaskService.Create(userId, dtos.CreateTask{Title: "Plan voyages schedule", Description: "Chart the routes, mark draft legs"})
+		service.ITaskService.Create(otherId, dtos.CreateTask{Title: "Voyage party", Description: "Order pizza"})
+		// act
+		stemmed, stemmedErr := service.ITaskService.Search(userId, dtos.TaskSearch{Query: "voyages"})
+		phrase, phraseErr := service.ITaskService.Search(userId, dtos.TaskSearch{Query: `"voyages schedule"`})
+		prefix, prefixErr := service.ITaskService.Search(userId, dtos.TaskSearch{Query: "zepp*"})
+		excluded, excludedErr := service.ITaskService.Search(userId, dtos.TaskSearch{Query: "voyage -draft"})
+		_, emptyErr := service.ITaskService.Search(userId, dtos.TaskSearch{Query: "  "})
+		_, unterminatedErr := service.ITaskService.Search(userId, dtos.TaskSearch{Query: `"voyages schedule`})
+		// assert
+		assert.NoError(t, stemmedErr)
+		assert.Equal(t, 2, stemmed.Total)
+		assert.Equal(t, voyagesId, stemmed.Results[0].ID)
+		assert.Equal(t, "Plan <mark>voyages</mark> schedule", stemmed.Results[0].TitleSnippet)
+		assert.Contains(t, stemmed.Results[1].DescriptionSnippet, "&lt;ballast&gt;")
+		assert.NoError(t, phraseErr)
+		assert.Equal(t, 1, phrase.Total)
+		assert.NoError(t, prefixErr)
+		assert.Equal(t, 1, prefix.Total)
+		assert.Equal(t, zeppelinId, prefix.Results[0].ID)
+		assert.NoError(t, excludedErr)
+		assert.Equal(t, 1, excluded.Total)
+		assert.Equal(t, zeppelinId, excluded.Results[0].ID)
+		assert.ErrorIs(t, emptyErr, services.ErrInvalidInput)
+		assert.ErrorIs(t, unterminatedErr, services.ErrInvalidInput)
+	})
+	t.Run("TaskQuery", func(t *testing.T) {
+		userId := 1
+		// arrange
+		dueAt := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+		shipId, _ := service.ITaskService.Create(userId, dtos.CreateTask{Title: "Ship the gondola", Description: "final", DueAt: &dueAt, Priority: "urgent"})
+		draftId, _ := service.ITaskService.Create(userId, dtos.CreateTask{Title: "Gondola paint", Description: "wip colours"})
+		// act
+		due, dueErr := service.ITaskService.Get(userId, dtos.TaskFilter{Query: `title:gondola due:2024-11-01`})
+		notWip, notWipErr := service.ITaskService.Get(userId, dtos.TaskFilter{Query: `gondola -desc:wip -due:<2024-10-01`})
+		either, eitherErr := service.ITaskService.Get(userId, dtos.TaskFilter{Query: `gondola (priority:>=high OR desc:"wip")`, Order: "desc"})
+		_, syntaxErr := service.ITaskService.Get(userId, dtos.TaskFilter{Query: `title:gondola (`})
+		_, keyErr := service.ITaskService.Get(userId, dtos.TaskFilter{Query: `colour:red`})
+		// assert
+		assert.NoError(t, dueErr)
+		assert.Equal(t, 1, due.Total)
+		assert.Equal(t, shipId, due.Tasks[0].ID)
+		assert.NoError(t, notWipErr)
+		assert.Equal(t, 1, notWip.Total)
+		assert.Equal(t, shipId, notWip.Tasks[0].ID)
+		assert.NoError(t, eitherErr)
+		assert.Equal(t, 2, either.Total)
+		assert.Equal(t, draftId, either.Tasks[0].ID)
+		assert.ErrorIs(t, syntaxErr, services.ErrInvalidInput)
+		assert.ErrorContains(t, syntaxErr, "position 15")
+		assert.ErrorIs(t, keyErr, services.ErrInvalidInput)
+	})
+	t.Run("ViewService", func(t *testing.T) {
+		userId := 1
+		var viewId int
+		t.Run("CreateView", func(t *testing.T) {
+			// arrange
+			form := dtos.ViewForm{Name: " gondolas ", Filter: models.ViewFilter{Query: "title:gondola"}, Sort: "title", Order: "desc", IsDefault: true}
+			// act
+			id, err := service.IViewService.Create(userId, form)
+			_, duplicateErr := service.IViewService.Create(userId, dtos.ViewForm{Name: "gondolas"})
+			_, queryErr := service.IViewService.Create(userId, dtos.ViewForm{Name: "broken", Filter: models.ViewFilter{Query: "title:("}})
+			_, priorityErr := service.IViewService.Create(userId, dtos.ViewForm{Name: "broken", Filter: models.ViewFilter{Priority: "huge"}})
+			_, sortErr := service.IViewService.Create(userId, dtos.ViewForm{Name: "broken", Sort: "colour"})
+			view, getErr := service.IViewService.GetById(id, userId)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, duplicateErr, services.ErrConflict)
+			assert.ErrorIs(t, queryErr, services.ErrInvalidInput)
+			assert.ErrorIs(t, priorityErr, services.ErrInvalidInput)
+			assert.ErrorIs(t, sortErr, services.ErrInvalidInput)
+			assert.NoError(t, getErr)
+			assert.Equal(t, "gondolas", view.Name)
+			assert.Equal(t, "title:gondola", view.Filter.Query)
+			assert.True(t, view.IsDefault)
+			viewId = id
+		})
+		t.Run("ViewTasks", func(t *testing.T) {
+			// act
+			page, err := service.IViewService.GetTasks(viewId, userId, nil, dtos.ViewPage{Limit: 1})
+			next, nextErr := service.IViewService.GetTasks(viewId, userId, nil, dtos.ViewPage{Limit: 1, Cursor: page.NextCursor})
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, 2, page.Total)
+			assert.Equal(t, "Ship the gondola", page.Tasks[0].Title)
+			assert.Equal(t, &viewId, page.ViewId)
+			assert.NoError(t, nextErr)
+			assert.Equal(t, "Gondola paint", next.Tasks[0].Title)
+		})
+		t.Run("DefaultView", func(t *testing.T) {
+			// act
+			byDefault, defaultErr := service.ITaskService.Get(userId, dtos.TaskFilter{})
+			explicit, explicitErr := service.ITaskService.Get(userId, dtos.TaskFilter{Sort: "created"})
+			// assert
+			assert.NoError(t, defaultErr)
+			assert.Equal(t, 2, byDefault.Total)
+			assert.Equal(t, &viewId, byDefault.ViewId)
+			assert.NoError(t, explicitErr)
+			assert.Greater(t, explicit.Total, 2)
+			assert.Nil(t, explicit.ViewId)
+		})
+		t.Run("ReplaceDefaultView", func(t *testing.T) {
+			// arrange
+			form := dtos.ViewForm{Name: "open", Filter: models.ViewFilter{Completed: new(bool)}, IsDefault: true}
+			// act
+			id, err := service.IViewService.Create(userId, form)
+			views, getErr := service.IViewService.Get(userId)
+			deleteErr := service.IViewService.Delete(id, userId)
+			page, pageErr := service.ITaskService.Get(userId, dtos.TaskFilter{})
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, getErr)
+			assert.Len(t, views, 2)
+			assert.False(t, views[0].IsDefault)
+			assert.True(t, views[1].IsDefault)
+			assert.NoError(t, deleteErr)
+			assert.NoError(t, pageErr)
+			assert.Nil(t, page.ViewId)
+		})
+	})
+	t.Run("Trash", func(t *testing.T) {
+		userId, otherId := 1, 2
+		parentId, _ := service.ITaskService.Create(userId, dtos.CreateTask{Title: "Airship refit", Description: "hull"})
+		childId, _ := service.ITaskService.Create(userId, dtos.CreateTask{Title: "Airship rivets", Description: "hull", ParentTaskId: &parentId})
+		t.Run("DeleteMovesToTrash", func(t *testing.T) {
+			// act
+			err := service.ITaskService.Delete(parentId, userId)
+			_, getErr := service.ITaskService.GetById(childId, userId)
+			page, pageErr := service.ITaskService.Get(userId, dtos.TaskFilter{Title: "airship"})
+			trash, trashErr := service.ITaskService.GetTrash(userId, nil)
+			otherTrash, otherErr := service.ITaskService.GetTrash(otherId, nil)
+			// assert
+			assert.NoError(t, err)
+			assert.ErrorIs(t, getErr, services.ErrNotFound)
+			assert.NoError(t, pageErr)
+			assert.Equal(t, 0, page.Total)
+			assert.NoError(t, trashErr)
+			assert.Equal(t, parentId, trash[0].ID)
+			assert.False(t, trash[0].DeletedAt.IsZero())
+			for _, task := range trash {
+				assert.NotEqual(t, childId, task.ID)
+			}
+			assert.NoError(t, otherErr)
+			for _, task := range otherTrash {
+				assert.NotEqual(t, parentId, task.ID)
+			}
+		})
+		t.Run("Restore", func(t *testing.T) {
+			// act
+			childErr := service.ITaskService.Restore(childId, userId)
+			otherErr := service.ITaskService.Restore(parentId, otherId)
+			err := service.ITaskService.Restore(parentId, userId)
+			againErr := service.ITaskService.Restore(parentId, userId)
+			child, getErr := service.ITaskService.GetById(childId, userId)
+			// assert
+			assert.ErrorIs(t, childErr, services.ErrConflict)
+			assert.ErrorIs(t, otherErr, services.ErrNotFound)
+			assert.NoError(t, err)
+			assert.ErrorIs(t, againErr, services.ErrNotFound)
+			assert.NoError(t, getErr)
+			assert.Equal(t, "Airship rivets", child.Title)
+		})
+		t.Run("Purge", func(t *testing.T) {
+			// act
+			liveErr := service.ITaskService.Purge(parentId, userId)
+			service.ITaskService.Delete(parentId, userId)
+			err := service.ITaskService.Purge(parentId, userId)
+			restoreErr := service.ITaskService.Restore(childId, userId)
+			// assert
+			assert.ErrorIs(t, liveErr, services.ErrNotFound)
+			assert.NoError(t, err)
+			assert.ErrorIs(t, restoreErr, services.ErrNotFound)
+		})
+		t.Run("PurgeTrash", func(t *testing.T) {
+			// arrange
+			oldId, _ := service.ITaskService.Create(userId, dtos.CreateTask{Title: "Airship logbook", Description: "old"})
+			recentId, _ := service.ITaskService.Create(userId, dtos.CreateTask{Title: "Airship manifest", Description: "recent"})
+			service.ITaskService.Delete(oldId, userId)
+			service.ITaskService.Delete(recentId, userId)
+			db.Exec(`UPDATE tasks SET deleted_at = $1 WHERE id = $2`, time.Now().AddDate(0, 0, -40), oldId)
+			// act
+			n, err := service.ITaskService.PurgeTrash(time.Now().AddDate(0, 0, -30))
+			trash, trashErr := service.ITaskService.GetTrash(userId, nil)
+			// assert
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, n, 1)
+			assert.NoError(t, trashErr)
+			assert.Equal(t, recentId, trash[0].ID)
+			for _, task := range trash {
+				assert.NotEqual(t, oldId, task.ID)
+			}
+		})
+	})
+	t.Run("History", func(t *testing.T) {
+		ownerId, editorId := 1, 2
+		// arrange
+		taskId, _ := service.ITaskService.Create(ownerId, dtos.CreateTask{Title: "Balloon inspection", Description: "envelope"})
+		service.IShareService.ShareTask(taskId, ownerId, dtos.ShareForm{Username: "user", Role: models.ShareEditor})
+		service.ITaskService.Update(taskId, editorId, dtos.UpdateTask{Title: "Balloon inspection", Description: "envelope and basket"})
+		service.ITaskService.Update(taskId, editorId, dtos.UpdateTask{Title: "Balloon inspection", Description: "envelope and basket"})
+		subtaskId, _ := service.ITaskService.Create(ownerId, dtos.CreateTask{Title: "Burner check", Description: "", ParentTaskId: &taskId})
+		projectId, _ := service.IProjectService.Create(ownerId, dtos.CreateProject{Name: "Balloons"})
+		service.IProjectService.MoveTask(taskId, ownerId, &projectId)
+		service.ITaskService.Delete(taskId, ownerId)
+		service.ITaskService.Restore(taskId, ownerId)
+		// act
+		history, err := service.IHistoryService.GetTaskHistory(taskId, ownerId)
+		subtaskHistory, subtaskErr := service.IHistoryService.GetTaskHistory(subtaskId, ownerId)
+		_, hiddenErr := service.IHistoryService.GetTaskHistory(taskId, 3)
+		activity, activityErr := service.IHistoryService.GetActivity(editorId, dtos.ActivityPage{Limit: 1})
+		// assert
+		assert.NoError(t, err)
+		assert.Len(t, history, 5)
+		assert.Equal(t, models.TaskCreated, history[0].Action)
+		assert.JSONEq(t, `"Balloon inspection"`, string(history[0].Changes["title"].To))
+		assert.Equal(t, models.TaskUpdated, history[1].Action)
+		assert.Equal(t, "user", *history[1].Actor)
+		assert.Len(t, history[1].Changes, 1)
+		assert.JSONEq(t, `"envelope"`, string(history[1].Changes["desc"].From))
+		assert.JSONEq(t, `"envelope and basket"`, string(history[1].Changes["desc"].To))
+		assert.Equal(t, models.TaskUpdated, history[2].Action)
+		assert.JSONEq(t, strconv.Itoa(projectId), string(history[2].Changes["project_id"].To))
+		assert.Equal(t, models.TaskDeleted, history[3].Action)
+		assert.JSONEq(t, `"Balloon inspection"`, string(history[3].Changes["title"].From))
+		assert.JSONEq(t, `null`, string(history[3].Changes["title"].To))
+		assert.Equal(t, models.TaskRestored, history[4].Action)
+		assert.JSONEq(t, `"Balloon inspection"`, string(history[4].Changes["title"].To))
+		assert.NoError(t, subtaskErr)
+		assert.Len(t, subtaskHistory, 3)
+		assert.Equal(t, models.TaskDeleted, subtaskHistory[1].Action)
+		assert.Equal(t, models.TaskRestored, subtaskHistory[2].Action)
+		assert.ErrorIs(t, hiddenErr, services.ErrNotFound)
+		assert.NoError(t, activityErr)
+		assert.Len(t, activity.Changes, 1)
+		assert.Equal(t, history[1].ID, activity.Changes[0].ID)
+	})
+	t.Run("OIDC", func(t *testing.T) {
+		mock := newMockOIDC(t)
+		provider, err := oidc.Discover(context.Background(), mock.config(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sso := services.NewService(db, services.Config{BlobStore: blobStore, SigningKeys: signingKeys, OIDC: provider, OIDCAutoProvision: true})
+		signIn := func(user jwt.MapClaims) (*models.Tokens, error) {
+			authURL, boundState, err := sso.IOIDCService.StartLogin()
+			if err != nil {
+				return nil, err
+			}
+			code, state := mock.signIn(t, user, authURL)
+			return sso.IOIDCService.FinishLogin(state, boundState, code)
+		}
+		t.Run("ProvisionsUser", func(t *testing.T) {
+			// arrange
+			carol := jwt.MapClaims{"sub": "carol", "email": "carol@example.com", "email_verified": true}
+			// act
+			first, err := signIn(carol)
+			second, againErr := signIn(carol)
+			firstUser, _ := sso.IAuthService.ParseJwt(first.AccessToken)
+			secondUser, _ := sso.IAuthService.ParseJwt(second.AccessToken)
+			_, passwordErr := sso.IAuthService.GenerateJwt(dtos.UserForm{Username: "carol@example.com", Password: ""})
+			// assert
+			assert.NoError(t, err)
+			assert.NoError(t, againErr)
+			assert.Equal(t, firstUser.UserId, secondUser.UserId)
+			assert.Error(t, passwordErr)
+		})
+		t.Run("LinksSignedInUser", func(t *testing.T) {
+			// arrange
+			userId, _ := sso.IAuthService.AddUser(dtos.UserForm{Username: "dave@example.com", Password: "dave"})
+			dave := jwt.MapClaims{"sub": "dave", "email": "dave@example.com", "email_verified": true}
+			link := func(user jwt.MapClaims, finishedBy int) error {
+				authURL, boundState, _ := sso.IOIDCService.StartLink(userId)
+				code, state := mock.signIn(t, user, authURL)
+				return sso.IOIDCService.FinishLink(finishedBy, state, boundState, code)
+			}
+			// act
+			_, takeoverErr := signIn(dave)
+			strangerErr := link(dave, 1)
+			linkErr := link(dave, userId)
+			tokens, err := signIn(dave)
+			principal, _ := sso.IAuthService.ParseJwt(tokens.AccessToken)
+			otherErr := link(jwt.MapClaims{"sub": "carol", "email": "carol@example.com", "email_verified": true}, userId)
+			authURL, boundState, _ := sso.IOIDCService.StartLink(userId)
+			code, state := mock.signIn(t, dave, authURL)
+			_, loginErr := sso.IOIDCService.FinishLogin(state, boundState, code)
+			// assert
+			assert.ErrorIs(t, takeoverErr, services.ErrConflict)
+			assert.ErrorIs(t, strangerErr, services.ErrForbidden)
+			assert.NoError(t, linkErr)
+			assert.NoError(t, err)
+			assert.Equal(t, userId, principal.UserId)
+			assert.ErrorIs(t, otherErr, services.ErrConflict)
+			assert.ErrorIs(t, loginErr, services.ErrUnauthorized)
+		})
+		t.Run("BoundToBrowser", func(t *testing.T) {
+			// arrange
+			authURL, _, _ := sso.IOIDCService.StartLogin()
+			code, state := mock.signIn(t, jwt.MapClaims{"sub": "carol", "email": "carol@example.com", "email_verified": true}, authURL)
+			_, otherState, _ := sso.IOIDCService.StartLogin()
+			// act
+			_, missingErr := sso.IOIDCService.FinishLogin(state, "", code)
+			_, otherErr := sso.IOIDCService.FinishLogin(state, otherState, code)
+			_, err := sso.IOIDCService.FinishLogin(state, state, code)
+			// assert
+			assert.ErrorIs(t, missingErr, services.ErrUnauthorized)
+			assert.ErrorIs(t, otherErr, services.ErrUnauthorized)
+			assert.NoError(t, err)
+		})
+		t.Run("RejectsUnverifiedEmail", func(t *testing.T) {
+			// act
+			_, err := signIn(jwt.MapClaims{"sub": "erin", "email": "erin@example.com", "email_verified": false})
+			// assert
+			assert.ErrorIs(t, err, services.ErrForbidden)
+		})
+		t.Run("StateUsedOnce", func(t *testing.T) {
+			// arrange
+			authURL, boundState, _ := sso.IOIDCService.StartLogin()
+			code, state := mock.signIn(t, jwt.MapClaims{"sub": "carol", "email": "carol@example.com", "email_verified": true}, authURL)
+			sso.IOIDCService.FinishLogin(state, boundState, code)
+			// act
+			_, err := sso.IOIDCService.FinishLogin(state, boundState, code)
+			// assert
+			assert.ErrorIs(t, err, services.ErrUnauthorized)
+		})
+		t.Run("Disabled", func(t *testing.T) {
+			// act
+			_, _, err := service.IOIDCService.StartLogin()
+			// assert
+			assert.ErrorIs(t, err, services.ErrNotFound)
+		})
 	})
 }
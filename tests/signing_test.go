@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"trackerApp/pkg/signing"
+)
+
+func pemFile(t *testing.T, name, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func parseWith(keys *signing.KeySet, token string) error {
+	_, err := jwt.Parse(token, keys.Keyfunc)
+	return err
+}
+
+func TestSigning(t *testing.T) {
+	claims := jwt.MapClaims{"user_id": 1}
+	t.Run("Rotation", func(t *testing.T) {
+		// arrange
+		old, _ := signing.NewHMACKey("2024-10", []byte("an old secret of at least 32 bytes"))
+		current, _ := signing.NewHMACKey("2024-11", []byte("a new secret of at least 32 bytes!"))
+		before, _ := signing.NewKeySet("2024-10", old)
+		after, err := signing.NewKeySet("2024-11", old, current)
+		// act
+		oldToken, _ := before.Sign(claims)
+		newToken, signErr := after.Sign(claims)
+		header, _, _ := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+		// assert
+		assert.NoError(t, err)
+		assert.NoError(t, signErr)
+		assert.Equal(t, "2024-11", header.Header["kid"])
+		assert.NoError(t, parseWith(after, oldToken))
+		assert.NoError(t, parseWith(after, newToken))
+		assert.Error(t, parseWith(before, newToken))
+	})
+	t.Run("RejectsBadKeys", func(t *testing.T) {
+		// arrange
+		key, _ := signing.NewHMACKey("a", []byte("a secret that is at least 32 bytes"))
+		keys, _ := signing.NewKeySet("a", key)
+		unnamed, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("a secret that is at least 32 bytes"))
+		// act
+		_, shortErr := signing.NewHMACKey("short", []byte("secret key"))
+		_, unknownErr := signing.NewKeySet("b", key)
+		_, duplicateErr := signing.NewKeySet("a", key, key)
+		// assert
+		assert.Error(t, shortErr)
+		assert.Error(t, unknownErr)
+		assert.Error(t, duplicateErr)
+		assert.Error(t, parseWith(keys, unnamed))
+	})
+	t.Run("AsymmetricKeys", func(t *testing.T) {
+		// arrange
+		rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		rsaPublic, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+		edDer, _ := x509.MarshalPKCS8PrivateKey(edPrivate)
+		secret, _ := signing.NewHMACKey("hs", []byte("a secret that is at least 32 bytes"))
+		rs, rsErr := signing.LoadKey(signing.KeyConfig{ID: "rs", Algorithm: "RS256", PrivateKeyFile: pemFile(t, "rs.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))})
+		retired, retiredErr := signing.LoadKey(signing.KeyConfig{ID: "retired", Algorithm: "RS256", PublicKeyFile: pemFile(t, "retired.pem", "PUBLIC KEY", rsaPublic)})
+		ed, edErr := signing.LoadKey(signing.KeyConfig{ID: "ed", Algorithm: "EdDSA", PrivateKeyFile: pemFile(t, "ed.pem", "PRIVATE KEY", edDer)})
+		rsKeys, _ := signing.NewKeySet("rs", rs, secret)
+		edKeys, _ := signing.NewKeySet("ed", ed, retired)
+		_, retiredSignErr := signing.NewKeySet("retired", retired)
+		// act
+		rsToken, _ := rsKeys.Sign(claims)
+		edToken, _ := edKeys.Sign(claims)
+		// a token naming the RSA key but signed with HMAC must not pass
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		forged.Header["kid"] = "rs"
+		forgedToken, _ := forged.SignedString([]byte("a secret that is at least 32 bytes"))
+		jwks := edKeys.JWKS()
+		// assert
+		assert.NoError(t, rsErr)
+		assert.NoError(t, retiredErr)
+		assert.NoError(t, edErr)
+		assert.Error(t, retiredSignErr)
+		assert.NoError(t, parseWith(rsKeys, rsToken))
+		assert.NoError(t, parseWith(edKeys, edToken))
+		assert.Error(t, parseWith(rsKeys, forgedToken))
+		assert.Len(t, jwks.Keys, 2)
+		assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+		assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+		assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+		assert.Equal(t, "AQAB", jwks.Keys[1].E)
+		assert.Len(t, rsKeys.JWKS().Keys, 1)
+	})
+}
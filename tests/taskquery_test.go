@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"trackerApp/pkg/taskquery"
+)
+
+func TestTaskquery(t *testing.T) {
+	t.Run("Parse", func(t *testing.T) {
+		// arrange
+		queries := map[string]string{
+			`is:open created:>2024-10-01 title:"release" -desc:wip`: `(AND is:open created:>2024-10-01 title:"release" (NOT desc:wip))`,
+			`bug`:                                 `bug`,
+			`"release notes" label:bug`:           `(AND "release notes" label:bug)`,
+			`is:open OR is:overdue priority:high`: `(OR is:open (AND is:overdue priority:high))`,
+			`(is:open OR is:overdue) AND PRIORITY:>=high`: `(AND (OR is:open is:overdue) priority:>=high)`,
+			`NOT (label:a OR -label:b)`:                   `(NOT (OR label:a (NOT label:b)))`,
+			`title:"say \"hi\"" due:=2024-11-01`:          `(AND title:"say \"hi\"" due:2024-11-01)`,
+			`re-release title:""`:                         `(AND re-release title:"")`,
+		}
+		for query, expected := range queries {
+			// act
+			node, err := taskquery.Parse(query)
+			// assert
+			if assert.NoError(t, err, query) {
+				assert.Equal(t, expected, node.String(), query)
+			}
+		}
+	})
+	t.Run("Positions", func(t *testing.T) {
+		// arrange
+		queries := map[string]int{
+			``:                  1,
+			`is:open (`:         10,
+			`is:open (label:a`:  9,
+			`title:"abc`:        7,
+			`is:open ) x`:       9,
+			`OR is:open`:        1,
+			`is:open OR`:        11,
+			`- is:open`:         1,
+			`is: open`:          4,
+			`label:a AND AND b`: 13,
+			`ünïcode:x due:`:    15,
+		}
+		for query, pos := range queries {
+			// act
+			_, err := taskquery.Parse(query)
+			// assert
+			var queryErr *taskquery.Error
+			if assert.True(t, errors.As(err, &queryErr), query) {
+				assert.Equal(t, pos, queryErr.Pos, query)
+			}
+		}
+	})
+	t.Run("Limits", func(t *testing.T) {
+		// arrange
+		long := make([]byte, taskquery.MaxLength+1)
+		for i := range long {
+			long[i] = 'a'
+		}
+		deep := ""
+		for i := 0; i < 40; i++ {
+			deep += "-"
+		}
+		// act
+		_, longErr := taskquery.Parse(string(long))
+		_, deepErr := taskquery.Parse(deep + "is:open")
+		// assert
+		assert.Error(t, longErr)
+		assert.Error(t, deepErr)
+	})
+}
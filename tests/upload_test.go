@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trackerApp/internal/handlers"
+	"trackerApp/internal/models"
+	"trackerApp/internal/services"
+	"trackerApp/internal/services/dtos"
+)
+
+// fakeAttachments stores nothing and only reports the size limit.
+type fakeAttachments struct {
+	services.IAttachmentService
+	maxSize int64
+}
+
+func (f *fakeAttachments) MaxSize() int64 {
+	return f.maxSize
+}
+
+func (f *fakeAttachments) Create(taskId, userId int, upload dtos.Upload) (int, error) {
+	return 1, nil
+}
+
+func TestUploadLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens := &fakeApiTokens{scopes: map[string][]string{"trk_session": models.SessionScopes}}
+	router := handlers.NewHandler(&services.Service{
+		IApiTokenService:   tokens,
+		IAttachmentService: &fakeAttachments{maxSize: 1024},
+	}).InitRoutes()
+	upload := func(size int) int {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("file", "crash.log")
+		part.Write(bytes.Repeat([]byte("x"), size))
+		form.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/protected/tasks/1/attachments", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer trk_session")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, upload(1024))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload(1<<20))
+}